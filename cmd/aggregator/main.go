@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"time"
 
+	"github.com/carbonable/leaderboard/internal/config"
 	appdb "github.com/carbonable/leaderboard/internal/db"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/charmbracelet/log"
@@ -11,6 +14,20 @@ import (
 
 func main() {
 	log.Info("Starting leaderboard aggregator")
+	network := os.Getenv("NETWORK")
+	cfg, err := config.FromYamlFile(fmt.Sprintf("contracts.%s.yaml", network))
+	if err != nil {
+		log.Fatalf("failed to get config from file: %v", err)
+	}
+	scoring, err := config.ScoringFromYamlFile(fmt.Sprintf("scoring.%s.yaml", network))
+	if err != nil {
+		log.Fatalf("failed to get scoring config from file: %v", err)
+	}
+	seasons := leaderboard.SeasonsFromConfig(cfg.Seasons)
+	if err := leaderboard.ValidateScoring(scoring, seasons); err != nil {
+		log.Fatalf("invalid scoring config: %v", err)
+	}
+	log.Info("Scoring rules loaded", "version", scoring.Version)
 
 	db, err := appdb.GetDbConnection()
 	if err != nil {
@@ -18,7 +35,7 @@ func main() {
 		return
 	}
 
-	aggregator := leaderboard.NewPgAggregrator(db)
+	aggregator := leaderboard.NewPgAggregrator(db, leaderboard.WithScoring(scoring), leaderboard.WithSeasons(seasons...), leaderboard.WithProtocolAddresses(cfg.ProtocolAddresses()...), leaderboard.WithExclusions(leaderboard.ExclusionsFromConfig(cfg.Exclusions, cfg.Contracts)...))
 	for {
 		go aggregator.Run(context.Background())
 		time.Sleep(1 * time.Minute)
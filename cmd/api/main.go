@@ -1,12 +1,15 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
 
 	"github.com/carbonable/leaderboard/internal/api"
+	"github.com/carbonable/leaderboard/internal/config"
 	appdb "github.com/carbonable/leaderboard/internal/db"
 	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
 )
 
@@ -30,6 +33,20 @@ func main() {
 		return
 	}
 
+	scoring, err := config.ScoringFromYamlFile(fmt.Sprintf("scoring.%s.yaml", network))
+	if err != nil {
+		log.Fatalf("failed to get scoring config from file: %v", err)
+	}
+	cfg, err := config.FromYamlFile(fmt.Sprintf("contracts.%s.yaml", network))
+	if err != nil {
+		log.Fatalf("failed to get config from file: %v", err)
+	}
+	// NOTE: same protocol addresses as aggregator so that explained scores match ranked ones
+	rules, err := leaderboard.NewScoringRules(scoring, leaderboard.NewPgMinterBuyValueAggregator(db, rpc), cfg.ProtocolAddresses()...)
+	if err != nil {
+		log.Fatalf("invalid scoring config: %v", err)
+	}
+
 	storage := indexer.NewPgStorage(db)
-	api.Run(storage, db, rpc)
+	api.Run(storage, db, rpc, rules, starknet.StarknetNetwork(network))
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/carbonable/leaderboard/internal/catalog"
 	"github.com/carbonable/leaderboard/internal/config"
 	appdb "github.com/carbonable/leaderboard/internal/db"
 	"github.com/carbonable/leaderboard/internal/indexer"
@@ -67,6 +68,11 @@ func main() {
 	}
 
 	storage := indexer.NewPgStorage(db)
+	go func() {
+		if err := catalog.SyncContracts(db, rpc, cfg.Contracts); err != nil {
+			log.Printf("failed to sync project contracts: %v", err)
+		}
+	}()
 
 	if err = subscriber.RegisterSubscribers(subscriber.NewSubscriberArgs(nc, db, storage, cfg, rpc)); err != nil {
 		panic(err)
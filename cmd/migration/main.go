@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 
+	"github.com/carbonable/leaderboard/internal/catalog"
 	infradb "github.com/carbonable/leaderboard/internal/db"
 	"github.com/carbonable/leaderboard/internal/indexer"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/carbonable/leaderboard/internal/starknet"
 	"github.com/charmbracelet/log"
 	"github.com/holiman/uint256"
 	"gorm.io/gorm"
@@ -24,11 +26,30 @@ func main() {
 
 	if *fresh {
 		log.Info("Dropping all tables")
-		_ = db.Migrator().DropTable(&leaderboard.DomainEvent{}, &leaderboard.LeaderboardLine{}, &leaderboard.MinterBuyValue{})
+		_ = db.Migrator().DropTable(&leaderboard.DomainEvent{}, &leaderboard.LeaderboardLine{}, &leaderboard.MinterBuyValue{}, &leaderboard.Season{}, &leaderboard.SeasonLeaderboardLine{}, &leaderboard.ReferralCode{}, &leaderboard.Referral{}, &leaderboard.SignatureNonce{}, &leaderboard.WalletExclusion{}, &leaderboard.WalletLink{}, &leaderboard.LineScore{}, &leaderboard.ProjectLeaderboardLine{}, &leaderboard.ScoreChange{}, &leaderboard.ProtocolStat{}, &catalog.Project{}, &catalog.ProjectContract{})
 	}
 
-	_ = db.AutoMigrate(&leaderboard.DomainEvent{}, &leaderboard.LeaderboardLine{}, &leaderboard.MinterBuyValue{}, &indexer.KVStore{})
+	_ = db.AutoMigrate(&leaderboard.DomainEvent{}, &leaderboard.LeaderboardLine{}, &leaderboard.MinterBuyValue{}, &leaderboard.Season{}, &leaderboard.SeasonLeaderboardLine{}, &leaderboard.ReferralCode{}, &leaderboard.Referral{}, &leaderboard.SignatureNonce{}, &leaderboard.WalletExclusion{}, &leaderboard.WalletLink{}, &leaderboard.LineScore{}, &leaderboard.ProjectLeaderboardLine{}, &leaderboard.ScoreChange{}, &leaderboard.ProtocolStat{}, &catalog.Project{}, &catalog.ProjectContract{}, &indexer.KVStore{})
+	if err := leaderboard.EnsureUniqueLineWallets(db); err != nil {
+		log.Fatalf("failed to add unique index on leaderboard lines: %v", err)
+	}
 	clearMinterBuyValue(db)
+	if err := leaderboard.NormalizeStoredAddresses(db); err != nil {
+		log.Fatalf("failed to normalize stored addresses: %v", err)
+	}
+	if err := leaderboard.BackfillRanks(db); err != nil {
+		log.Fatalf("failed to backfill ranks: %v", err)
+	}
+	if err := leaderboard.BackfillLineScores(db); err != nil {
+		log.Fatalf("failed to backfill line scores: %v", err)
+	}
+	storage := indexer.NewPgStorage(db)
+	err = leaderboard.BackfillEventBlockNumbers(db, func(contract string) (map[uint64][]string, error) {
+		return indexer.ContractEventIds(storage, starknet.EnsureStarkFelt(contract))
+	})
+	if err != nil {
+		log.Fatalf("failed to backfill event block numbers: %v", err)
+	}
 
 	log.Info("Migration done !")
 }
@@ -0,0 +1,47 @@
+package graph
+
+import "github.com/carbonable/leaderboard/graph/model"
+
+// Fixed cost of replaying the whole history of a wallet through score calculators
+const scoreReplayCost = 1000
+
+// Lists are as costly as their fields times the number of items requested
+func NewComplexity() ComplexityRoot {
+	var c ComplexityRoot
+	c.Query.Leaderboard = func(childComplexity int, pagination model.Pagination, seasonID *string, view *model.LeaderboardView) int {
+		return childComplexity * pagination.Limit
+	}
+	c.Query.ProjectLeaderboard = func(childComplexity int, project string, pagination model.Pagination) int {
+		return childComplexity * pagination.Limit
+	}
+	c.Query.LeaderboardConnection = func(childComplexity int, first *int, after *string, last *int, before *string, seasonID *string, view *model.LeaderboardView) int {
+		return childComplexity * pageSizeOf(first, last)
+	}
+	c.Query.LeaderboardAround = func(childComplexity int, walletAddress string, radius int, seasonID *string) int {
+		return childComplexity * (2*radius + 1)
+	}
+	c.Query.WalletActivity = func(childComplexity int, walletAddress string, filter *model.ActivityFilter, first *int, after *string) int {
+		return childComplexity * pageSizeOf(first, nil)
+	}
+	c.Query.ExplainScore = func(childComplexity int, walletAddress string) int {
+		return childComplexity + scoreReplayCost
+	}
+	// NOTE: history is replayed with and without simulated actions
+	c.Query.SimulateScore = func(childComplexity int, walletAddress string, actions []*model.SimulatedAction, horizonDays *int) int {
+		return childComplexity + 2*scoreReplayCost
+	}
+	c.Subscription.LeaderboardUpdated = func(childComplexity int, top *int) int {
+		return childComplexity * pageSizeOf(top, nil)
+	}
+	return c
+}
+
+func pageSizeOf(first *int, last *int) int {
+	switch {
+	case last != nil && *last > 0:
+		return *last
+	case first != nil && *first > 0:
+		return *first
+	}
+	return defaultPageSize
+}
@@ -2,10 +2,57 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// from and until are RFC3339 dates
+type ActivityFilter struct {
+	EventNames []string `json:"event_names,omitempty"`
+	Project    *string  `json:"project,omitempty"`
+	From       *string  `json:"from,omitempty"`
+	Until      *string  `json:"until,omitempty"`
+}
+
+type BoostFactor struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Value       int    `json:"value"`
+}
+
 type BoostForValue struct {
-	Value      string `json:"value"`
-	TotalScore string `json:"total_score"`
-	Boost      string `json:"boost"`
+	Value          string `json:"value"`
+	TotalScore     string `json:"total_score"`
+	Boost          string `json:"boost"`
+	MilestoneBoost string `json:"milestone_boost"`
+}
+
+type BoosterTrace struct {
+	Booster string `json:"booster"`
+	Fired   bool   `json:"fired"`
+	// coef in percent, 0 when booster did not fire
+	Coef   int         `json:"coef"`
+	Inputs []*KeyValue `json:"inputs"`
+}
+
+// Builder outcome on an event. Points are in 10^6 units
+type BuilderTrace struct {
+	Builder string `json:"builder"`
+	// applied, skipped or no_score
+	Status   string          `json:"status"`
+	Reason   *string         `json:"reason,omitempty"`
+	Rule     *string         `json:"rule,omitempty"`
+	Event    *string         `json:"event,omitempty"`
+	EventID  *string         `json:"event_id,omitempty"`
+	Date     *string         `json:"date,omitempty"`
+	Base     *string         `json:"base,omitempty"`
+	Points   *string         `json:"points,omitempty"`
+	Stacking *string         `json:"stacking,omitempty"`
+	Capped   bool            `json:"capped"`
+	Negative bool            `json:"negative"`
+	Boosts   []*BoosterTrace `json:"boosts"`
 }
 
 type Categories struct {
@@ -14,18 +61,70 @@ type Categories struct {
 	Other   string `json:"other"`
 }
 
+type ConnectionPageInfo struct {
+	HasNextPage     bool    `json:"has_next_page"`
+	HasPreviousPage bool    `json:"has_previous_page"`
+	StartCursor     *string `json:"start_cursor,omitempty"`
+	EndCursor       *string `json:"end_cursor,omitempty"`
+}
+
+type EventTrace struct {
+	EventID  string          `json:"event_id"`
+	Event    string          `json:"event"`
+	Date     string          `json:"date"`
+	Project  *string         `json:"project,omitempty"`
+	Builders []*BuilderTrace `json:"builders"`
+}
+
+type KeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 type Leaderboard struct {
 	Data     []*LeaderboardLineData `json:"data"`
 	PageInfo *PageInfo              `json:"page_info"`
 }
 
+type LeaderboardConnection struct {
+	Edges    []*LeaderboardEdge  `json:"edges"`
+	PageInfo *ConnectionPageInfo `json:"page_info"`
+}
+
+type LeaderboardEdge struct {
+	Cursor string               `json:"cursor"`
+	Node   *LeaderboardLineData `json:"node"`
+}
+
 type LeaderboardLineData struct {
 	ID            string          `json:"id"`
 	WalletAddress string          `json:"wallet_address"`
 	Points        []*PointDetails `json:"points"`
 	Categories    *Categories     `json:"categories"`
 	TotalScore    string          `json:"total_score"`
+	RulesVersion  string          `json:"rules_version"`
 	Position      int             `json:"position"`
+	Wallets       []*WalletScore  `json:"wallets"`
+}
+
+// Top lines of all-time leaderboard once an aggregation run changed them
+type LeaderboardUpdate struct {
+	UpdatedAt string                 `json:"updated_at"`
+	Lines     []*LeaderboardLineData `json:"lines"`
+}
+
+// Ranking sorted by one score and restricted to current holders of a project or slot. Positions are relative to the view
+type LeaderboardView struct {
+	Sort    *LeaderboardSort `json:"sort,omitempty"`
+	SortKey *string          `json:"sort_key,omitempty"`
+	Project *string          `json:"project,omitempty"`
+	// requires project
+	Slot *int `json:"slot,omitempty"`
+}
+
+type LinkedWallet struct {
+	WalletAddress string `json:"wallet_address"`
+	LinkedAt      string `json:"linked_at"`
 }
 
 type Metadata struct {
@@ -37,10 +136,15 @@ type Metadata struct {
 	Rule        *string `json:"rule,omitempty"`
 }
 
+type Mutation struct {
+}
+
 type NextBoostForValue struct {
-	Missing    string `json:"missing"`
-	TotalScore string `json:"total_score"`
-	Boost      string `json:"boost"`
+	Missing            string `json:"missing"`
+	TotalScore         string `json:"total_score"`
+	Boost              string `json:"boost"`
+	MilestoneMissing   string `json:"milestone_missing"`
+	MilestoneNextBoost string `json:"milestone_next_boost"`
 }
 
 type PageInfo struct {
@@ -57,11 +161,379 @@ type Pagination struct {
 	Limit int `json:"limit"`
 }
 
+type PointBreakdown struct {
+	Base     int            `json:"base"`
+	Boosts   []*BoostFactor `json:"boosts"`
+	Stacking string         `json:"stacking"`
+	Capped   bool           `json:"capped"`
+	Final    int            `json:"final"`
+}
+
 type PointDetails struct {
-	Rule     *string   `json:"rule,omitempty"`
-	Value    *int      `json:"value,omitempty"`
-	Metadata *Metadata `json:"metadata,omitempty"`
+	Rule      *string         `json:"rule,omitempty"`
+	Value     *int            `json:"value,omitempty"`
+	Metadata  *Metadata       `json:"metadata,omitempty"`
+	Breakdown *PointBreakdown `json:"breakdown,omitempty"`
+}
+
+// Project slot metadata as last read from chain
+type Project struct {
+	Address     string              `json:"address"`
+	Slot        int                 `json:"slot"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Image       string              `json:"image"`
+	ExternalURL string              `json:"external_url"`
+	YoutubeURL  string              `json:"youtube_url"`
+	Attributes  []*ProjectAttribute `json:"attributes"`
+	Contracts   []*ProjectContract  `json:"contracts"`
+	Funding     *ProjectFunding     `json:"funding"`
+	UpdatedAt   string              `json:"updated_at"`
+}
+
+type ProjectAttribute struct {
+	TraitType   string `json:"trait_type"`
+	DisplayType string `json:"display_type"`
+	Value       string `json:"value"`
+}
+
+// Protocol contract working on a project slot, kind is minter, offseter, yielder or migrator
+type ProjectContract struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type ProjectFunding struct {
+	// $ bought and airdropped
+	Value   string `json:"value"`
+	Funders int    `json:"funders"`
 }
 
 type Query struct {
 }
+
+type Referee struct {
+	WalletAddress  string `json:"wallet_address"`
+	ReferrerWallet string `json:"referrer_wallet"`
+	Code           string `json:"code"`
+	Source         string `json:"source"`
+	ReferredAt     string `json:"referred_at"`
+}
+
+type ReferralCode struct {
+	Code          string `json:"code"`
+	WalletAddress string `json:"wallet_address"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type Referrals struct {
+	Codes    []*ReferralCode `json:"codes"`
+	Referees []*Referee      `json:"referees"`
+}
+
+// Replay of a wallet all-time score. total_score = (positive_points - negative_points) / divisor
+type ScoreExplanation struct {
+	WalletAddress  string          `json:"wallet_address"`
+	Events         []*EventTrace   `json:"events"`
+	History        []*BuilderTrace `json:"history"`
+	PositivePoints string          `json:"positive_points"`
+	NegativePoints string          `json:"negative_points"`
+	Divisor        int             `json:"divisor"`
+	TotalScore     string          `json:"total_score"`
+	// score of the stored line, differs from total_score when events came after last aggregation
+	StoredTotalScore *string `json:"stored_total_score,omitempty"`
+}
+
+// Ranks are 0 when wallet is excluded from ranking, rank_change is positive when wallet climbs
+type ScoreSimulation struct {
+	WalletAddress string          `json:"wallet_address"`
+	Current       *SimulatedScore `json:"current"`
+	Simulated     *SimulatedScore `json:"simulated"`
+	CurrentRank   int             `json:"current_rank"`
+	ProjectedRank int             `json:"projected_rank"`
+	// True with a horizon, other wallets keep their current score so projected rank is the best rank wallet can expect
+	ProjectedRankLowerBound bool `json:"projected_rank_lower_bound"`
+	RankChange              int  `json:"rank_change"`
+}
+
+type Season struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	StartAt string `json:"start_at"`
+	EndAt   string `json:"end_at"`
+	Rules   string `json:"rules"`
+	Frozen  bool   `json:"frozen"`
+}
+
+// Hypothetical action. amount is in $, or in tCO2 for CLAIM_OFFSET. contract is yielder or offseter for stakes
+type SimulatedAction struct {
+	Kind     SimulatedActionKind `json:"kind"`
+	Project  string              `json:"project"`
+	Slot     *int                `json:"slot,omitempty"`
+	Amount   string              `json:"amount"`
+	Contract *string             `json:"contract,omitempty"`
+}
+
+type SimulatedScore struct {
+	TotalScore string      `json:"total_score"`
+	Categories *Categories `json:"categories"`
+}
+
+type StatPoint struct {
+	// start of bucket, weeks start on monday UTC
+	Bucket string `json:"bucket"`
+	Value  string `json:"value"`
+}
+
+// Protocol time series, recomputed after each aggregation run
+type StatSeries struct {
+	Metric   StatMetric   `json:"metric"`
+	Interval StatInterval `json:"interval"`
+	Project  *string      `json:"project,omitempty"`
+	Slot     *int         `json:"slot,omitempty"`
+	// last point of PARTICIPANTS, sum of points otherwise
+	Total      string       `json:"total"`
+	ComputedAt *string      `json:"computed_at,omitempty"`
+	Points     []*StatPoint `json:"points"`
+}
+
+type Subscription struct {
+}
+
+// Domain event of a wallet decoded for display. Amounts are in $
+type WalletActivity struct {
+	EventID   string  `json:"event_id"`
+	TxHash    *string `json:"tx_hash,omitempty"`
+	BlockTime string  `json:"block_time"`
+	EventName string  `json:"event_name"`
+	Source    string  `json:"source"`
+	Project   *string `json:"project,omitempty"`
+	Slot      *string `json:"slot,omitempty"`
+	Amount    *string `json:"amount,omitempty"`
+	TokenID   *string `json:"token_id,omitempty"`
+	From      *string `json:"from,omitempty"`
+	To        *string `json:"to,omitempty"`
+	// points earned by event in all-time ranking, empty when event earned none
+	Points []*PointDetails `json:"points"`
+}
+
+type WalletActivityConnection struct {
+	Edges    []*WalletActivityEdge `json:"edges"`
+	PageInfo *ConnectionPageInfo   `json:"page_info"`
+}
+
+type WalletActivityEdge struct {
+	Cursor string          `json:"cursor"`
+	Node   *WalletActivity `json:"node"`
+}
+
+type WalletIdentity struct {
+	IdentityWallet string          `json:"identity_wallet"`
+	Wallets        []*LinkedWallet `json:"wallets"`
+}
+
+type WalletScore struct {
+	WalletAddress string `json:"wallet_address"`
+	TotalScore    string `json:"total_score"`
+}
+
+// Previous values are null for wallets ranked for the first time. Ranks are 0 for excluded wallets
+type WalletScoreChange struct {
+	WalletAddress      string  `json:"wallet_address"`
+	TotalScore         string  `json:"total_score"`
+	PreviousTotalScore *string `json:"previous_total_score,omitempty"`
+	Rank               int     `json:"rank"`
+	PreviousRank       *int    `json:"previous_rank,omitempty"`
+	UpdatedAt          string  `json:"updated_at"`
+}
+
+type LeaderboardSort string
+
+const (
+	LeaderboardSortTotal   LeaderboardSort = "TOTAL"
+	LeaderboardSortFund    LeaderboardSort = "FUND"
+	LeaderboardSortFarming LeaderboardSort = "FARMING"
+	LeaderboardSortOther   LeaderboardSort = "OTHER"
+	// points from the rule given as sort_key
+	LeaderboardSortRule LeaderboardSort = "RULE"
+	// points earned on the project given as sort_key
+	LeaderboardSortProject LeaderboardSort = "PROJECT"
+)
+
+var AllLeaderboardSort = []LeaderboardSort{
+	LeaderboardSortTotal,
+	LeaderboardSortFund,
+	LeaderboardSortFarming,
+	LeaderboardSortOther,
+	LeaderboardSortRule,
+	LeaderboardSortProject,
+}
+
+func (e LeaderboardSort) IsValid() bool {
+	switch e {
+	case LeaderboardSortTotal, LeaderboardSortFund, LeaderboardSortFarming, LeaderboardSortOther, LeaderboardSortRule, LeaderboardSortProject:
+		return true
+	}
+	return false
+}
+
+func (e LeaderboardSort) String() string {
+	return string(e)
+}
+
+func (e *LeaderboardSort) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LeaderboardSort(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LeaderboardSort", str)
+	}
+	return nil
+}
+
+func (e LeaderboardSort) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SimulatedActionKind string
+
+const (
+	SimulatedActionKindBuy         SimulatedActionKind = "BUY"
+	SimulatedActionKindClaimYield  SimulatedActionKind = "CLAIM_YIELD"
+	SimulatedActionKindClaimOffset SimulatedActionKind = "CLAIM_OFFSET"
+	SimulatedActionKindStake       SimulatedActionKind = "STAKE"
+	SimulatedActionKindUnstake     SimulatedActionKind = "UNSTAKE"
+)
+
+var AllSimulatedActionKind = []SimulatedActionKind{
+	SimulatedActionKindBuy,
+	SimulatedActionKindClaimYield,
+	SimulatedActionKindClaimOffset,
+	SimulatedActionKindStake,
+	SimulatedActionKindUnstake,
+}
+
+func (e SimulatedActionKind) IsValid() bool {
+	switch e {
+	case SimulatedActionKindBuy, SimulatedActionKindClaimYield, SimulatedActionKindClaimOffset, SimulatedActionKindStake, SimulatedActionKindUnstake:
+		return true
+	}
+	return false
+}
+
+func (e SimulatedActionKind) String() string {
+	return string(e)
+}
+
+func (e *SimulatedActionKind) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SimulatedActionKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SimulatedActionKind", str)
+	}
+	return nil
+}
+
+func (e SimulatedActionKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type StatInterval string
+
+const (
+	StatIntervalDay  StatInterval = "DAY"
+	StatIntervalWeek StatInterval = "WEEK"
+)
+
+var AllStatInterval = []StatInterval{
+	StatIntervalDay,
+	StatIntervalWeek,
+}
+
+func (e StatInterval) IsValid() bool {
+	switch e {
+	case StatIntervalDay, StatIntervalWeek:
+		return true
+	}
+	return false
+}
+
+func (e StatInterval) String() string {
+	return string(e)
+}
+
+func (e *StatInterval) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatInterval(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatInterval", str)
+	}
+	return nil
+}
+
+func (e StatInterval) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type StatMetric string
+
+const (
+	// $ bought on minters, airdrops included
+	StatMetricFunded StatMetric = "FUNDED"
+	// tCO2 claimed on offseters
+	StatMetricOffset StatMetric = "OFFSET"
+	// $ claimed on yielders
+	StatMetricYield StatMetric = "YIELD"
+	// unique participants since launch
+	StatMetricParticipants    StatMetric = "PARTICIPANTS"
+	StatMetricNewParticipants StatMetric = "NEW_PARTICIPANTS"
+)
+
+var AllStatMetric = []StatMetric{
+	StatMetricFunded,
+	StatMetricOffset,
+	StatMetricYield,
+	StatMetricParticipants,
+	StatMetricNewParticipants,
+}
+
+func (e StatMetric) IsValid() bool {
+	switch e {
+	case StatMetricFunded, StatMetricOffset, StatMetricYield, StatMetricParticipants, StatMetricNewParticipants:
+		return true
+	}
+	return false
+}
+
+func (e StatMetric) String() string {
+	return string(e)
+}
+
+func (e *StatMetric) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StatMetric(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StatMetric", str)
+	}
+	return nil
+}
+
+func (e StatMetric) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
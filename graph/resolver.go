@@ -2,6 +2,7 @@ package graph
 
 import (
 	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
 	"gorm.io/gorm"
 )
@@ -16,12 +17,18 @@ type Resolver struct {
 	storage indexer.Storage
 	db      *gorm.DB
 	rpc     starknet.StarknetRpcClient
+	rules   *leaderboard.ScoringRules
+	network starknet.StarknetNetwork
+	updates *leaderboard.UpdateBroker
 }
 
-func NewGraphResolver(storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient) *Resolver {
+func NewGraphResolver(storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient, rules *leaderboard.ScoringRules, network starknet.StarknetNetwork, updates *leaderboard.UpdateBroker) *Resolver {
 	return &Resolver{
 		storage: storage,
 		db:      db,
 		rpc:     rpc,
+		rules:   rules,
+		network: network,
+		updates: updates,
 	}
 }
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/carbonable/leaderboard/graph/model"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"gotest.tools/assert"
+)
+
+func TestValidatePagination(t *testing.T) {
+	testCases := []struct {
+		name       string
+		pagination model.Pagination
+		err        error
+	}{
+		{name: "first page", pagination: model.Pagination{Page: 1, Limit: 10}},
+		{name: "largest page", pagination: model.Pagination{Page: 3, Limit: leaderboard.MaxPageSize}},
+		{name: "page zero", pagination: model.Pagination{Page: 0, Limit: 10}, err: leaderboard.ErrInvalidPage},
+		{name: "negative page", pagination: model.Pagination{Page: -1, Limit: 10}, err: leaderboard.ErrInvalidPage},
+		{name: "empty page", pagination: model.Pagination{Page: 1, Limit: 0}, err: leaderboard.ErrInvalidPageSize},
+		{name: "page too large", pagination: model.Pagination{Page: 1, Limit: leaderboard.MaxPageSize + 1}, err: leaderboard.ErrInvalidPageSize},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, validatePagination(tc.pagination), tc.err)
+		})
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	c := NewComplexity()
+	first := 20
+
+	assert.Equal(t, c.Query.Leaderboard(3, model.Pagination{Page: 1, Limit: 50}, nil, nil), 150)
+	assert.Equal(t, c.Query.LeaderboardConnection(3, &first, nil, nil, nil, nil, nil), 60)
+	assert.Equal(t, c.Query.ExplainScore(3, "0x1"), 3+scoreReplayCost)
+	assert.Equal(t, c.Query.SimulateScore(3, "0x1", nil, nil), 3+2*scoreReplayCost)
+}
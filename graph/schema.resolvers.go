@@ -6,109 +6,680 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/NethermindEth/juno/core/felt"
 	"github.com/carbonable/leaderboard/graph/model"
+	"github.com/carbonable/leaderboard/internal/catalog"
 	appdb "github.com/carbonable/leaderboard/internal/db"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/charmbracelet/log"
 	"github.com/holiman/uint256"
+	ulid "github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
 )
 
+// RegisterReferralCode is the resolver for the registerReferralCode field.
+func (r *mutationResolver) RegisterReferralCode(ctx context.Context, walletAddress string, code string, signature []string) (*model.ReferralCode, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	err = leaderboard.VerifySignature(r.rpc, leaderboard.SigningDomain(r.network), wallet, leaderboard.ReferralCodeMessage(code), signature)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := leaderboard.RegisterReferralCode(r.db.WithContext(ctx), wallet, code)
+	if err != nil {
+		return nil, err
+	}
+	return referralCodeToGqlModel(*rc), nil
+}
+
+// ClaimReferral is the resolver for the claimReferral field.
+func (r *mutationResolver) ClaimReferral(ctx context.Context, walletAddress string, code string, nonce string, expiry int, signature []string) (*model.Referee, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	n, exp, err := signatureNonce(nonce, expiry)
+	if err != nil {
+		return nil, err
+	}
+	err = leaderboard.VerifySignature(r.rpc, leaderboard.SigningDomain(r.network), wallet, leaderboard.ReferralClaimMessage(code, n, exp), signature)
+	if err != nil {
+		return nil, err
+	}
+	var referral *leaderboard.Referral
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := leaderboard.UseSignatureNonce(tx, wallet, n, exp, now)
+		if err != nil {
+			return err
+		}
+		referral, err = leaderboard.ClaimReferral(tx, wallet, code, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refereeToGqlModel(*referral), nil
+}
+
+// LinkWallet is the resolver for the linkWallet field.
+func (r *mutationResolver) LinkWallet(ctx context.Context, identityWallet string, walletAddress string, nonce string, expiry int, identitySignature []string, walletSignature []string) (*model.WalletIdentity, error) {
+	identity, err := normalizeWallet(identityWallet)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	n, exp, err := signatureNonce(nonce, expiry)
+	if err != nil {
+		return nil, err
+	}
+	domain := leaderboard.SigningDomain(r.network)
+	message := leaderboard.WalletLinkMessage(identity, wallet, n, exp)
+	if err := leaderboard.VerifySignature(r.rpc, domain, identity, message, identitySignature); err != nil {
+		return nil, err
+	}
+	if err := leaderboard.VerifySignature(r.rpc, domain, wallet, message, walletSignature); err != nil {
+		return nil, err
+	}
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for _, w := range []string{identity, wallet} {
+			if err := leaderboard.UseSignatureNonce(tx, w, n, exp, now); err != nil {
+				return err
+			}
+		}
+		_, err := leaderboard.LinkWallet(tx, identity, wallet, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return walletIdentity(r.db.WithContext(ctx), identity)
+}
+
+// UnlinkWallet is the resolver for the unlinkWallet field.
+func (r *mutationResolver) UnlinkWallet(ctx context.Context, walletAddress string, signer string, nonce string, expiry int, signature []string) (*model.WalletIdentity, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	signerWallet, err := normalizeWallet(signer)
+	if err != nil {
+		return nil, err
+	}
+	n, exp, err := signatureNonce(nonce, expiry)
+	if err != nil {
+		return nil, err
+	}
+	if err := leaderboard.VerifySignature(r.rpc, leaderboard.SigningDomain(r.network), signerWallet, leaderboard.WalletUnlinkMessage(wallet, n, exp), signature); err != nil {
+		return nil, err
+	}
+	var link *leaderboard.WalletLink
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := leaderboard.UseSignatureNonce(tx, signerWallet, n, exp, time.Now())
+		if err != nil {
+			return err
+		}
+		link, err = leaderboard.UnlinkWallet(tx, wallet, signerWallet)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return walletIdentity(r.db.WithContext(ctx), link.IdentityWallet)
+}
+
 // Leaderboard is the resolver for the leaderboard field.
-func (r *queryResolver) Leaderboard(ctx context.Context, pagination model.Pagination) (*model.Leaderboard, error) {
+func (r *queryResolver) Leaderboard(ctx context.Context, pagination model.Pagination, seasonID *string, view *model.LeaderboardView) (*model.Leaderboard, error) {
+	if err := validatePagination(pagination); err != nil {
+		return nil, err
+	}
 	var lines []leaderboardQueryResult
 	var count int64
-	r.db.Model(&leaderboard.LeaderboardLine{}).Count(&count)
-	r.db.Raw(appdb.PaginateRaw(leaderboardQuery, pagination.Page, pagination.Limit)).Scan(&lines)
+	if rv := rankingView(view); !rv.IsDefault() {
+		season, err := parseSeasonID(seasonID)
+		if err != nil {
+			return nil, err
+		}
+		ranked, total, err := leaderboard.GetRankingViewPage(r.db.WithContext(ctx), season, rv, (pagination.Page-1)*pagination.Limit, pagination.Limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range ranked {
+			lines = append(lines, leaderboardQueryResult(l))
+		}
+		count = total
+	} else if seasonID != nil {
+		id, err := ulid.Parse(*seasonID)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.db.WithContext(ctx).Model(&leaderboard.SeasonLeaderboardLine{}).Where("season_id = ?", id).Where(leaderboard.RankedLineCondition).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if err := r.db.WithContext(ctx).Raw(appdb.PaginateRaw(seasonLeaderboardQuery, pagination.Page, pagination.Limit), id).Scan(&lines).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		if err := r.db.WithContext(ctx).Model(&leaderboard.LeaderboardLine{}).Where(leaderboard.RankedLineCondition).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if err := r.db.WithContext(ctx).Raw(appdb.PaginateRaw(leaderboardQuery, pagination.Page, pagination.Limit)).Scan(&lines).Error; err != nil {
+			return nil, err
+		}
+	}
 	data := dbModelToGqlModel(lines)
 
-	totalPages := math.Ceil(float64(count) / float64(pagination.Limit))
-
 	return &model.Leaderboard{
 		Data: data,
 
-		PageInfo: &model.PageInfo{
-			MaxPage:         int(totalPages),
-			Page:            pagination.Page,
-			Limit:           pagination.Limit,
-			Count:           int(count),
-			HasNextPage:     pagination.Page < int(totalPages),
-			HasPreviousPage: pagination.Page > 1,
-		},
+		PageInfo: pageInfo(pagination, count),
 	}, nil
 }
 
+// LeaderboardConnection is the resolver for the leaderboardConnection field.
+func (r *queryResolver) LeaderboardConnection(ctx context.Context, first *int, after *string, last *int, before *string, seasonID *string, view *model.LeaderboardView) (*model.LeaderboardConnection, error) {
+	season, err := parseSeasonID(seasonID)
+	if err != nil {
+		return nil, err
+	}
+	afterCursor, err := parseCursor(after)
+	if err != nil {
+		return nil, err
+	}
+	beforeCursor, err := parseCursor(before)
+	if err != nil {
+		return nil, err
+	}
+	size, lastSize := defaultPageSize, 0
+	if first != nil {
+		size = *first
+	}
+	if last != nil {
+		lastSize = *last
+	}
+
+	page, err := leaderboard.GetRankingPage(r.db.WithContext(ctx), season, rankingView(view), size, afterCursor, lastSize, beforeCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.LeaderboardConnection{
+		Edges: []*model.LeaderboardEdge{},
+		PageInfo: &model.ConnectionPageInfo{
+			HasNextPage:     page.HasNextPage,
+			HasPreviousPage: page.HasPreviousPage,
+		},
+	}
+	for _, l := range page.Lines {
+		connection.Edges = append(connection.Edges, &model.LeaderboardEdge{
+			Cursor: leaderboard.CursorFromLine(l).Encode(),
+			Node:   itemToGqlModel(leaderboardQueryResult(l)),
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// LeaderboardAround is the resolver for the leaderboardAround field.
+func (r *queryResolver) LeaderboardAround(ctx context.Context, walletAddress string, radius int, seasonID *string) ([]*model.LeaderboardLineData, error) {
+	season, err := parseSeasonID(seasonID)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := leaderboard.IdentityOf(r.db.WithContext(ctx), wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := leaderboard.GetRankingAround(r.db.WithContext(ctx), season, identity, radius)
+	if err != nil {
+		return nil, err
+	}
+	data := []*model.LeaderboardLineData{}
+	for _, l := range lines {
+		data = append(data, itemToGqlModel(leaderboardQueryResult(l)))
+	}
+	return data, nil
+}
+
 // LeaderboardForWallet is the resolver for the leaderboardForWallet field.
-func (r *queryResolver) LeaderboardForWallet(ctx context.Context, walletAddress string) (*model.LeaderboardLineData, error) {
-	var walletFelt felt.Felt
-	err := walletFelt.UnmarshalJSON([]byte(walletAddress))
+func (r *queryResolver) LeaderboardForWallet(ctx context.Context, walletAddress string, seasonID *string) (*model.LeaderboardLineData, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: linked wallets are ranked under their identity
+	identity, err := leaderboard.IdentityOf(r.db.WithContext(ctx), wallet)
 	if err != nil {
 		return nil, err
 	}
 	var line leaderboardQueryResult
-	res := r.db.Raw(leaderboardQueryWhere, walletFelt.String()).Scan(&line)
+	var res *gorm.DB
+	if seasonID != nil {
+		id, err := ulid.Parse(*seasonID)
+		if err != nil {
+			return nil, err
+		}
+		res = r.db.WithContext(ctx).Raw(seasonLeaderboardQueryWhere, id, identity).Scan(&line)
+	} else {
+		res = r.db.WithContext(ctx).Raw(leaderboardQueryWhere, identity).Scan(&line)
+	}
 	data := itemToGqlModel(line)
 	return data, res.Error
 }
 
+// ProjectLeaderboard is the resolver for the projectLeaderboard field.
+func (r *queryResolver) ProjectLeaderboard(ctx context.Context, project string, pagination model.Pagination) (*model.Leaderboard, error) {
+	if err := validatePagination(pagination); err != nil {
+		return nil, err
+	}
+	var lines []leaderboardQueryResult
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&leaderboard.ProjectLeaderboardLine{}).Where("project = ?", project).Where(leaderboard.RankedLineCondition).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Raw(appdb.PaginateRaw(projectLeaderboardQuery, pagination.Page, pagination.Limit), project).Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	return &model.Leaderboard{
+		Data:     dbModelToGqlModel(lines),
+		PageInfo: pageInfo(pagination, count),
+	}, nil
+}
+
+// Seasons is the resolver for the seasons field.
+func (r *queryResolver) Seasons(ctx context.Context) ([]*model.Season, error) {
+	seasons, err := leaderboard.GetSeasons(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	var data []*model.Season
+	for _, s := range seasons {
+		data = append(data, &model.Season{
+			ID:      s.ID.String(),
+			Name:    s.Name,
+			StartAt: fmt.Sprintf("%d", s.StartAt.Unix()*1000),
+			EndAt:   fmt.Sprintf("%d", s.EndAt.Unix()*1000),
+			Rules:   s.Rules,
+			Frozen:  s.IsFrozen(),
+		})
+	}
+	return data, nil
+}
+
+// Referrals is the resolver for the referrals field.
+func (r *queryResolver) Referrals(ctx context.Context, walletAddress string) (*model.Referrals, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := leaderboard.GetReferralCodes(r.db.WithContext(ctx), wallet)
+	if err != nil {
+		return nil, err
+	}
+	referees, err := leaderboard.GetReferees(r.db.WithContext(ctx), wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &model.Referrals{Codes: []*model.ReferralCode{}, Referees: []*model.Referee{}}
+	for _, c := range codes {
+		data.Codes = append(data.Codes, referralCodeToGqlModel(c))
+	}
+	for _, referee := range referees {
+		data.Referees = append(data.Referees, refereeToGqlModel(referee))
+	}
+	return data, nil
+}
+
+// WalletIdentity is the resolver for the walletIdentity field.
+func (r *queryResolver) WalletIdentity(ctx context.Context, walletAddress string) (*model.WalletIdentity, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := leaderboard.IdentityOf(r.db.WithContext(ctx), wallet)
+	if err != nil {
+		return nil, err
+	}
+	return walletIdentity(r.db.WithContext(ctx), identity)
+}
+
+// ExplainScore is the resolver for the explainScore field.
+func (r *queryResolver) ExplainScore(ctx context.Context, walletAddress string) (*model.ScoreExplanation, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	line, trace, err := leaderboard.NewPgAggregrator(r.db.WithContext(ctx)).ExplainWallet(r.rules, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &model.ScoreExplanation{
+		WalletAddress:  line.WalletAddress,
+		Events:         []*model.EventTrace{},
+		History:        builderTracesToGqlModel(trace.History),
+		PositivePoints: trace.Positive,
+		NegativePoints: trace.Negative,
+		Divisor:        1000000,
+		TotalScore:     trace.Total,
+	}
+	for _, et := range trace.Events {
+		explanation.Events = append(explanation.Events, &model.EventTrace{
+			EventID:  et.Event.EventId,
+			Event:    et.Event.EventName,
+			Date:     et.Event.RecordedAt.Format(time.RFC3339),
+			Project:  optionalString(et.Event.Metadata["project_name"]),
+			Builders: builderTracesToGqlModel(et.Builders),
+		})
+	}
+	var stored leaderboard.LeaderboardLine
+	if err := r.db.WithContext(ctx).Where("wallet_address = ?", line.WalletAddress).First(&stored).Error; err == nil {
+		explanation.StoredTotalScore = &stored.TotalScore
+	}
+	return explanation, nil
+}
+
+// SimulateScore is the resolver for the simulateScore field.
+func (r *queryResolver) SimulateScore(ctx context.Context, walletAddress string, actions []*model.SimulatedAction, horizonDays *int) (*model.ScoreSimulation, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	horizon := 0
+	if horizonDays != nil {
+		horizon = *horizonDays
+	}
+	if horizon < 0 {
+		return nil, fmt.Errorf("horizon_days must be positive")
+	}
+	var simulated []leaderboard.SimulatedAction
+	for _, a := range actions {
+		simulated = append(simulated, simulatedActionFromGql(a))
+	}
+	simulation, err := leaderboard.NewPgAggregrator(r.db.WithContext(ctx)).SimulateWallet(r.rules, wallet, simulated, time.Duration(horizon)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ScoreSimulation{
+		WalletAddress:           simulation.Simulated.WalletAddress,
+		Current:                 simulatedScoreToGqlModel(simulation.Current),
+		Simulated:               simulatedScoreToGqlModel(simulation.Simulated),
+		CurrentRank:             simulation.CurrentRank,
+		ProjectedRank:           simulation.ProjectedRank,
+		ProjectedRankLowerBound: simulation.ProjectedRankIsLowerBound,
+		RankChange:              simulation.RankChange(),
+	}, nil
+}
+
+// Projects is the resolver for the projects field.
+func (r *queryResolver) Projects(ctx context.Context) ([]*model.Project, error) {
+	projects, err := catalog.GetProjects(r.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return r.projectsToGqlModel(ctx, projects)
+}
+
+// Project is the resolver for the project field.
+func (r *queryResolver) Project(ctx context.Context, address string, slot int) (*model.Project, error) {
+	if slot < 0 {
+		return nil, fmt.Errorf("slot must be positive")
+	}
+	p, err := catalog.GetProject(r.db.WithContext(ctx), address, uint64(slot))
+	if errors.Is(err, catalog.ErrProjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.projectsToGqlModel(ctx, []catalog.Project{*p})
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+// ProtocolStats is the resolver for the protocolStats field.
+func (r *queryResolver) ProtocolStats(ctx context.Context, metric model.StatMetric, interval model.StatInterval, project *string, slot *int, from *string, until *string) (*model.StatSeries, error) {
+	fromTime, err := parseOptionalTime(from)
+	if err != nil {
+		return nil, err
+	}
+	untilTime, err := parseOptionalTime(until)
+	if err != nil {
+		return nil, err
+	}
+	var projectName, slotFelt string
+	if project != nil {
+		projectName = *project
+	}
+	if slot != nil {
+		if projectName == "" {
+			return nil, fmt.Errorf("slot requires a project")
+		}
+		slotFelt = starknet.FeltFromUint64(uint64(*slot)).String()
+	}
+	m, i := strings.ToLower(metric.String()), strings.ToLower(interval.String())
+	if projectName != "" && (m == leaderboard.ParticipantsStat || m == leaderboard.NewParticipantsStat) {
+		return nil, fmt.Errorf("participants are not split by project")
+	}
+	series, err := leaderboard.GetStatSeries(r.db.WithContext(ctx), m, i, projectName, slotFelt, fromTime, untilTime)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &model.StatSeries{
+		Metric:   metric,
+		Interval: interval,
+		Project:  project,
+		Slot:     slot,
+		Total:    statValue(m, &series.Total),
+		Points:   []*model.StatPoint{},
+	}
+	if !series.ComputedAt.IsZero() {
+		computedAt := series.ComputedAt.Format(time.RFC3339)
+		res.ComputedAt = &computedAt
+	}
+	for _, p := range series.Points {
+		res.Points = append(res.Points, &model.StatPoint{Bucket: p.Bucket.Format(time.RFC3339), Value: statValue(m, &p.Value.Int)})
+	}
+	return res, nil
+}
+
+// WalletActivity is the resolver for the walletActivity field.
+func (r *queryResolver) WalletActivity(ctx context.Context, walletAddress string, filter *model.ActivityFilter, first *int, after *string) (*model.WalletActivityConnection, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	activityFilter, err := activityFilterFromGql(filter)
+	if err != nil {
+		return nil, err
+	}
+	var cursor *leaderboard.ActivityCursor
+	if after != nil {
+		if cursor, err = leaderboard.DecodeActivityCursor(*after); err != nil {
+			return nil, err
+		}
+	}
+	size := defaultPageSize
+	if first != nil {
+		size = *first
+	}
+
+	page, err := leaderboard.GetWalletActivity(r.db.WithContext(ctx), wallet, activityFilter, size, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	connection := &model.WalletActivityConnection{
+		Edges: []*model.WalletActivityEdge{},
+		PageInfo: &model.ConnectionPageInfo{
+			HasNextPage:     page.HasNextPage,
+			HasPreviousPage: cursor != nil,
+		},
+	}
+	for _, a := range page.Activities {
+		connection.Edges = append(connection.Edges, &model.WalletActivityEdge{
+			Cursor: leaderboard.ActivityCursor{RecordedAt: a.RecordedAt, ID: a.ID}.Encode(),
+			Node:   activityToGqlModel(a),
+		})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
 // BoostForWallet is the resolver for the boostForWallet field.
 func (r *queryResolver) BoostForWallet(ctx context.Context, walletAddress string, valueToBuy int, address string, slot int) (*model.BoostForValue, error) {
-	scm := leaderboard.MintPageCalculatorManager()
+	scm, err := r.rules.Manager(leaderboard.MintPageRules)
+	if err != nil {
+		return nil, err
+	}
 	// generate fake event to enable computation
-	buy := leaderboard.DomainEvent{
-		RecordedAt:    time.Now(),
-		EventName:     "minter:buy",
-		WalletAddress: walletAddress,
-		Data: map[string]string{
-			"value": fmt.Sprintf("0x%x", valueToBuy*1000000),
-		},
+	buy, err := mintPageBuyEvent(ctx, r.db, walletAddress, valueToBuy, address, slot)
+	if err != nil {
+		return nil, err
 	}
 	pr := leaderboard.NewPersonnalRanking(walletAddress, []leaderboard.DomainEvent{buy})
 	leaderboardLine := pr.ComputeScore(scm)
 
-	bc := leaderboard.DefaultProjectValueBoostCalculator()
+	bc, exists := leaderboard.FindBooster[*leaderboard.ProjectValueBoostCalculator](scm)
+	if !exists {
+		bc = leaderboard.DefaultProjectValueBoostCalculator()
+	}
 	boost, _, _ := bc.GetInterval(uint64(valueToBuy))
+	milestone, _, _ := milestoneInterval(scm, buy)
 	return &model.BoostForValue{
-		Value:      fmt.Sprintf("%d", valueToBuy),
-		TotalScore: leaderboardLine.TotalScore,
-		Boost:      boost.String(),
+		Value:          fmt.Sprintf("%d", valueToBuy),
+		TotalScore:     leaderboardLine.TotalScore,
+		Boost:          boost.String(),
+		MilestoneBoost: milestone.String(),
 	}, nil
 }
 
 // NextBoostForWallet is the resolver for the nextBoostForWallet field.
 func (r *queryResolver) NextBoostForWallet(ctx context.Context, walletAddress string, valueToBuy int, address string, slot int) (*model.NextBoostForValue, error) {
-	scm := leaderboard.MintPageCalculatorManager()
+	scm, err := r.rules.Manager(leaderboard.MintPageRules)
+	if err != nil {
+		return nil, err
+	}
 	// generate fake event to enable computation
-	buy := leaderboard.DomainEvent{
-		RecordedAt:    time.Now(),
-		EventName:     "minter:buy",
-		WalletAddress: walletAddress,
-		Data: map[string]string{
-			"value": fmt.Sprintf("0x%x", valueToBuy*1000000),
-		},
+	buy, err := mintPageBuyEvent(ctx, r.db, walletAddress, valueToBuy, address, slot)
+	if err != nil {
+		return nil, err
 	}
 	pr := leaderboard.NewPersonnalRanking(walletAddress, []leaderboard.DomainEvent{buy})
 	leaderboardLine := pr.ComputeScore(scm)
-	bc := leaderboard.DefaultProjectValueBoostCalculator()
+	bc, exists := leaderboard.FindBooster[*leaderboard.ProjectValueBoostCalculator](scm)
+	if !exists {
+		bc = leaderboard.DefaultProjectValueBoostCalculator()
+	}
 
 	_, next, boost := bc.GetInterval(uint64(valueToBuy))
 	var missing uint256.Int
 	missing.Sub(next, uint256.NewInt(uint64(valueToBuy)))
+	_, milestoneMissing, milestoneNextBoost := milestoneInterval(scm, buy)
 
 	return &model.NextBoostForValue{
-		Missing:    missing.String(),
-		TotalScore: leaderboardLine.TotalScore,
-		Boost:      boost.String(),
+		Missing:            missing.String(),
+		TotalScore:         leaderboardLine.TotalScore,
+		Boost:              boost.String(),
+		MilestoneMissing:   milestoneMissing.String(),
+		MilestoneNextBoost: milestoneNextBoost.String(),
 	}, nil
 }
 
+// LeaderboardUpdated is the resolver for the leaderboardUpdated field.
+func (r *subscriptionResolver) LeaderboardUpdated(ctx context.Context, top *int) (<-chan *model.LeaderboardUpdate, error) {
+	n := 10
+	if top != nil {
+		n = *top
+	}
+	if n < 1 || n > leaderboard.MaxPageSize {
+		return nil, leaderboard.ErrInvalidPageSize
+	}
+	return subscribeUpdates(ctx, r.updates, func(u leaderboard.LeaderboardUpdate) (*model.LeaderboardUpdate, bool) {
+		if !u.TopChanged(n) {
+			return nil, false
+		}
+		var lines []leaderboardQueryResult
+		if err := r.db.WithContext(ctx).Raw(appdb.PaginateRaw(leaderboardQuery, 1, n)).Scan(&lines).Error; err != nil {
+			log.Error("failed to get top lines", "error", err)
+			return nil, false
+		}
+		return &model.LeaderboardUpdate{UpdatedAt: u.UpdatedAt.Format(time.RFC3339), Lines: dbModelToGqlModel(lines)}, true
+	}), nil
+}
+
+// WalletScoreChanged is the resolver for the walletScoreChanged field.
+func (r *subscriptionResolver) WalletScoreChanged(ctx context.Context, walletAddress string) (<-chan *model.WalletScoreChange, error) {
+	wallet, err := normalizeWallet(walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	return subscribeUpdates(ctx, r.updates, func(u leaderboard.LeaderboardUpdate) (*model.WalletScoreChange, bool) {
+		// NOTE: identity is resolved on each update as wallet may be linked in between
+		identity, err := leaderboard.IdentityOf(r.db.WithContext(ctx), wallet)
+		if err != nil {
+			log.Error("failed to get wallet identity", "wallet", wallet, "error", err)
+			return nil, false
+		}
+		c, changed := u.WalletChange(identity)
+		if !changed {
+			return nil, false
+		}
+		return &model.WalletScoreChange{
+			WalletAddress:      c.WalletAddress,
+			TotalScore:         c.TotalScore,
+			PreviousTotalScore: c.PreviousTotalScore,
+			Rank:               c.Rank,
+			PreviousRank:       c.PreviousRank,
+			UpdatedAt:          u.UpdatedAt.Format(time.RFC3339),
+		}, true
+	}), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
 
 // !!! WARNING !!!
 // The code below was going to be deleted when updating resolvers. It has been copied here so you have
@@ -116,48 +687,53 @@ type queryResolver struct{ *Resolver }
 //   - When renaming or deleting a resolver the old code will be put in here. You can safely delete
 //     it when you're done.
 //   - You have helper methods in this file. Move them out to keep these resolver files clean.
-const leaderboardQuery = `WITH leaderboard AS (
-   SELECT l.*,
-	ROW_NUMBER() OVER(ORDER BY l.total_score::INT DESC) AS position
-     FROM leaderboard_lines l)
-SELECT l.*
-	FROM leaderboard l ORDER BY l.total_score::INT DESC;`
-const leaderboardQueryWhere = `WITH leaderboard AS (
-   SELECT l.*,
-	ROW_NUMBER() OVER(ORDER BY l.total_score::INT DESC) AS position
-     FROM leaderboard_lines l)
-SELECT l.*
-	FROM leaderboard l WHERE l.wallet_address = ? ORDER BY l.total_score::INT DESC;`
-
-type leaderboardQueryResult struct {
-	leaderboard.LeaderboardLine
-	Position int
-}
+const leaderboardQuery = `SELECT l.*, l.rank AS position
+	FROM leaderboard_lines l WHERE l.rank > 0 ORDER BY l.rank ASC;`
+const leaderboardQueryWhere = `SELECT l.*, l.rank AS position
+	FROM leaderboard_lines l WHERE l.wallet_address = ?;`
+const seasonLeaderboardQuery = `SELECT l.*, l.rank AS position
+	FROM season_leaderboard_lines l WHERE l.season_id = ? AND l.rank > 0 ORDER BY l.rank ASC;`
+const seasonLeaderboardQueryWhere = `SELECT l.*, l.rank AS position
+	FROM season_leaderboard_lines l WHERE l.season_id = ? AND l.wallet_address = ?;`
+const projectLeaderboardQuery = `SELECT l.*, l.rank AS position
+	FROM project_leaderboard_lines l WHERE l.project = ? AND l.rank > 0 ORDER BY l.rank ASC;`
 
-func itemToGqlModel(item leaderboardQueryResult) *model.LeaderboardLineData {
+type leaderboardQueryResult leaderboard.RankedLine
+
+func pointsToGqlModel(items []leaderboard.Point) []*model.PointDetails {
 	var points []*model.PointDetails
 
-	for _, point := range item.Points {
+	for _, point := range items {
 		slot := point.Metadata["slot"]
 		pName := point.Metadata["project_name"]
 		date := point.Metadata["date"]
 		event := point.Metadata["event"]
+		// NOTE: lines computed before breakdown existed only have display string in metadata
 		boosts := point.Metadata["boosts"]
+		if point.Breakdown != nil {
+			boosts = leaderboard.BoostsDisplay(point.Breakdown.Boosts)
+		}
 		rule := point.Metadata["rule"]
 		value := int(point.Value)
-		points = append(points, &model.PointDetails{Rule: &point.Rule, Value: &value, Metadata: &model.Metadata{Slot: &slot, ProjectName: &pName, Date: &date, Event: &event, Rule: &rule, Boosts: &boosts}})
+		points = append(points, &model.PointDetails{Rule: &point.Rule, Value: &value, Metadata: &model.Metadata{Slot: &slot, ProjectName: &pName, Date: &date, Event: &event, Rule: &rule, Boosts: &boosts}, Breakdown: breakdownToGqlModel(point.Breakdown)})
 	}
+	return points
+}
+func itemToGqlModel(item leaderboardQueryResult) *model.LeaderboardLineData {
+	points := pointsToGqlModel(item.Points)
 	return &model.LeaderboardLineData{
 		ID:            item.ID.String(),
 		WalletAddress: item.WalletAddress,
 		Points:        points,
 		TotalScore:    item.TotalScore,
+		RulesVersion:  item.RulesVersion,
 		Categories: &model.Categories{
 			Fund:    item.Categories.Fund,
 			Farming: item.Categories.Farming,
 			Other:   item.Categories.Other,
 		},
 		Position: item.Position,
+		Wallets:  walletScoresToGqlModel(item.Wallets),
 	}
 }
 func dbModelToGqlModel(dbModel []leaderboardQueryResult) []*model.LeaderboardLineData {
@@ -167,3 +743,361 @@ func dbModelToGqlModel(dbModel []leaderboardQueryResult) []*model.LeaderboardLin
 	}
 	return gqlModel
 }
+func mintPageBuyEvent(ctx context.Context, db *gorm.DB, walletAddress string, valueToBuy int, address string, slot int) (leaderboard.DomainEvent, error) {
+	// NOTE: project name comes from the catalog, mint page queries are public and must not wait for the rpc
+	project, err := catalog.GetProject(db.WithContext(ctx), address, uint64(slot))
+	if err != nil {
+		return leaderboard.DomainEvent{}, err
+	}
+
+	return leaderboard.DomainEvent{
+		RecordedAt:    time.Now(),
+		EventName:     "minter:buy",
+		WalletAddress: walletAddress,
+		Data: map[string]string{
+			"value": fmt.Sprintf("0x%x", valueToBuy*1000000),
+		},
+		Metadata: map[string]string{
+			"slot":         project.Slot,
+			"project_name": project.Name,
+		},
+	}, nil
+}
+func milestoneInterval(scm *leaderboard.ScoreCalculatorManager, buy leaderboard.DomainEvent) (boost *uint256.Int, missing *uint256.Int, nextBoost *uint256.Int) {
+	boost, missing, nextBoost = uint256.NewInt(0), uint256.NewInt(0), uint256.NewInt(0)
+	boosters := leaderboard.MatchingBoosters[*leaderboard.FundingMilestoneBoostCalculator](scm, buy)
+	if len(boosters) == 0 {
+		return boost, missing, nextBoost
+	}
+	bc := boosters[0]
+	value, err := bc.CurrentValue(buy)
+	if err != nil {
+		log.Error("failed to get project minter value", "project", bc.Project, "error", err)
+		return boost, missing, nextBoost
+	}
+
+	boost, next, nextBoost := bc.GetInterval(value.Uint64())
+	if next.Cmp(&value) > 0 {
+		missing.Sub(next, &value)
+	}
+	return boost, missing, nextBoost
+}
+func breakdownToGqlModel(b *leaderboard.PointBreakdown) *model.PointBreakdown {
+	if b == nil {
+		return nil
+	}
+	boosts := []*model.BoostFactor{}
+	for _, boost := range b.Boosts {
+		boosts = append(boosts, &model.BoostFactor{Name: boost.Name, DisplayName: boost.DisplayName, Value: boost.Value})
+	}
+	return &model.PointBreakdown{
+		Base:     int(b.Base),
+		Boosts:   boosts,
+		Stacking: string(b.Stacking),
+		Capped:   b.Capped,
+		Final:    int(b.Final),
+	}
+}
+func normalizeWallet(walletAddress string) (string, error) {
+	a, err := starknet.NewAddress(walletAddress)
+	return a.String(), err
+}
+func signatureNonce(nonce string, expiry int) (*felt.Felt, uint64, error) {
+	n, err := leaderboard.ParseNonce(nonce)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expiry < 0 {
+		return nil, 0, leaderboard.ErrSignatureExpired
+	}
+	return n, uint64(expiry), nil
+}
+func referralCodeToGqlModel(rc leaderboard.ReferralCode) *model.ReferralCode {
+	return &model.ReferralCode{
+		Code:          rc.Code,
+		WalletAddress: rc.WalletAddress,
+		CreatedAt:     fmt.Sprintf("%d", rc.CreatedAt.Unix()*1000),
+	}
+}
+func refereeToGqlModel(r leaderboard.Referral) *model.Referee {
+	return &model.Referee{
+		WalletAddress:  r.RefereeWallet,
+		ReferrerWallet: r.ReferrerWallet,
+		Code:           r.Code,
+		Source:         r.Source,
+		ReferredAt:     fmt.Sprintf("%d", r.CreatedAt.Unix()*1000),
+	}
+}
+func walletIdentity(db *gorm.DB, identity string) (*model.WalletIdentity, error) {
+	links, err := leaderboard.GetLinkedWallets(db, identity)
+	if err != nil {
+		return nil, err
+	}
+	data := &model.WalletIdentity{IdentityWallet: identity, Wallets: []*model.LinkedWallet{}}
+	for _, l := range links {
+		data.Wallets = append(data.Wallets, &model.LinkedWallet{WalletAddress: l.WalletAddress, LinkedAt: fmt.Sprintf("%d", l.CreatedAt.Unix()*1000)})
+	}
+	return data, nil
+}
+func walletScoresToGqlModel(wallets leaderboard.WalletScores) []*model.WalletScore {
+	data := []*model.WalletScore{}
+	for _, w := range wallets {
+		data = append(data, &model.WalletScore{WalletAddress: w.WalletAddress, TotalScore: w.TotalScore})
+	}
+	return data
+}
+
+const defaultPageSize = 20
+
+func parseSeasonID(seasonID *string) (*ulid.ULID, error) {
+	if seasonID == nil {
+		return nil, nil
+	}
+	id, err := ulid.Parse(*seasonID)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+func validatePagination(pagination model.Pagination) error {
+	if pagination.Page < 1 {
+		return leaderboard.ErrInvalidPage
+	}
+	if pagination.Limit < 1 || pagination.Limit > leaderboard.MaxPageSize {
+		return leaderboard.ErrInvalidPageSize
+	}
+	return nil
+}
+func pageInfo(pagination model.Pagination, count int64) *model.PageInfo {
+	totalPages := math.Ceil(float64(count) / float64(pagination.Limit))
+	return &model.PageInfo{
+		MaxPage:         int(totalPages),
+		Page:            pagination.Page,
+		Limit:           pagination.Limit,
+		Count:           int(count),
+		HasNextPage:     pagination.Page < int(totalPages),
+		HasPreviousPage: pagination.Page > 1,
+	}
+}
+func activityFilterFromGql(filter *model.ActivityFilter) (leaderboard.ActivityFilter, error) {
+	var f leaderboard.ActivityFilter
+	if filter == nil {
+		return f, nil
+	}
+	f.EventNames = filter.EventNames
+	if filter.Project != nil {
+		f.Project = *filter.Project
+	}
+	var err error
+	if f.From, err = parseOptionalTime(filter.From); err != nil {
+		return f, err
+	}
+	if f.Until, err = parseOptionalTime(filter.Until); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+func parseOptionalTime(s *string) (*time.Time, error) {
+	if s == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+func activityToGqlModel(a leaderboard.Activity) *model.WalletActivity {
+	points := []*model.PointDetails{}
+	points = append(points, pointsToGqlModel(a.Points)...)
+	return &model.WalletActivity{
+		EventID:   a.EventId,
+		TxHash:    optionalString(a.TxHash),
+		BlockTime: a.RecordedAt.Format(time.RFC3339),
+		EventName: a.EventName,
+		Source:    a.Source,
+		Project:   optionalString(a.Project),
+		Slot:      optionalString(a.Slot),
+		Amount:    optionalString(a.Amount),
+		TokenID:   optionalString(a.TokenId),
+		From:      optionalString(a.From),
+		To:        optionalString(a.To),
+		Points:    points,
+	}
+}
+func builderTracesToGqlModel(traces []leaderboard.BuilderTrace) []*model.BuilderTrace {
+	res := []*model.BuilderTrace{}
+	for _, bt := range traces {
+		t := &model.BuilderTrace{
+			Builder:  bt.Builder,
+			Status:   bt.Status,
+			Reason:   optionalString(bt.Reason),
+			Rule:     optionalString(string(bt.Rule)),
+			Base:     optionalString(bt.Base),
+			Points:   optionalString(bt.Points),
+			Stacking: optionalString(string(bt.Stacking)),
+			Capped:   bt.Capped,
+			Negative: bt.Negative,
+			Boosts:   []*model.BoosterTrace{},
+		}
+		if bt.Status == leaderboard.BuilderApplied {
+			t.Event = optionalString(bt.Event.EventName)
+			t.EventID = optionalString(bt.Event.EventId)
+			date := bt.Event.RecordedAt.Format(time.RFC3339)
+			t.Date = &date
+		}
+		for _, b := range bt.Boosts {
+			var keys []string
+			for k := range b.Inputs {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			inputs := []*model.KeyValue{}
+			for _, k := range keys {
+				inputs = append(inputs, &model.KeyValue{Key: k, Value: b.Inputs[k]})
+			}
+			t.Boosts = append(t.Boosts, &model.BoosterTrace{Booster: b.Booster, Fired: b.Fired, Coef: b.Coef, Inputs: inputs})
+		}
+		res = append(res, t)
+	}
+	return res
+}
+func rankingView(view *model.LeaderboardView) leaderboard.RankingView {
+	var rv leaderboard.RankingView
+	if view == nil {
+		return rv
+	}
+	if view.Project != nil {
+		rv.Project = *view.Project
+	}
+	if view.Slot != nil {
+		rv.Slot = starknet.FeltFromUint64(uint64(*view.Slot)).String()
+	}
+	if view.Sort == nil {
+		return rv
+	}
+	switch *view.Sort {
+	case model.LeaderboardSortFund:
+		rv.Dimension, rv.Key = leaderboard.CategoryDimension, leaderboard.FundCategory
+	case model.LeaderboardSortFarming:
+		rv.Dimension, rv.Key = leaderboard.CategoryDimension, leaderboard.FarmingCategory
+	case model.LeaderboardSortOther:
+		rv.Dimension, rv.Key = leaderboard.CategoryDimension, leaderboard.OtherCategory
+	case model.LeaderboardSortRule, model.LeaderboardSortProject:
+		rv.Dimension = leaderboard.RuleDimension
+		if *view.Sort == model.LeaderboardSortProject {
+			rv.Dimension = leaderboard.ProjectDimension
+		}
+		if view.SortKey != nil {
+			rv.Key = *view.SortKey
+		}
+	}
+	return rv
+}
+func parseCursor(cursor *string) (*leaderboard.Cursor, error) {
+	if cursor == nil {
+		return nil, nil
+	}
+	return leaderboard.DecodeCursor(*cursor)
+}
+func simulatedActionFromGql(a *model.SimulatedAction) leaderboard.SimulatedAction {
+	action := leaderboard.SimulatedAction{
+		Kind:    strings.ToLower(a.Kind.String()),
+		Project: a.Project,
+		Amount:  a.Amount,
+	}
+	if a.Slot != nil {
+		action.Slot = starknet.FeltFromUint64(uint64(*a.Slot)).String()
+	}
+	if a.Contract != nil {
+		action.Contract = *a.Contract
+	}
+	return action
+}
+func simulatedScoreToGqlModel(line *leaderboard.LeaderboardLine) *model.SimulatedScore {
+	return &model.SimulatedScore{
+		TotalScore: line.TotalScore,
+		Categories: &model.Categories{
+			Fund:    line.Categories.Fund,
+			Farming: line.Categories.Farming,
+			Other:   line.Categories.Other,
+		},
+	}
+}
+func subscribeUpdates[T any](ctx context.Context, broker *leaderboard.UpdateBroker, match func(leaderboard.LeaderboardUpdate) (*T, bool)) <-chan *T {
+	updates, cancel := broker.Subscribe()
+	ch := make(chan *T, 1)
+	go func() {
+		defer close(ch)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case u, ok := <-updates:
+				if !ok {
+					return
+				}
+				res, matches := match(u)
+				if !matches {
+					continue
+				}
+				select {
+				case ch <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+func statValue(metric string, v *uint256.Int) string {
+	if metric == leaderboard.ParticipantsStat || metric == leaderboard.NewParticipantsStat {
+		return v.Dec()
+	}
+	return leaderboard.FormatAmount(v.Hex())
+}
+func (r *queryResolver) projectsToGqlModel(ctx context.Context, projects []catalog.Project) ([]*model.Project, error) {
+	contracts, err := catalog.GetProjectsContracts(r.db.WithContext(ctx), projects)
+	if err != nil {
+		return nil, err
+	}
+	fundings, err := catalog.GetFundings(r.db.WithContext(ctx), projects)
+	if err != nil {
+		return nil, err
+	}
+	res := []*model.Project{}
+	for i := range projects {
+		res = append(res, projectToGqlModel(&projects[i], contracts[projects[i].Key()], fundings[projects[i].Key()]))
+	}
+	return res, nil
+}
+func projectToGqlModel(p *catalog.Project, contracts []catalog.ProjectContract, funding *catalog.Funding) *model.Project {
+	res := &model.Project{
+		Address:     p.Address,
+		Slot:        int(starknet.HexStringToUint64(p.Slot)),
+		Name:        p.Name,
+		Description: p.Description,
+		Image:       p.Image,
+		ExternalURL: p.ExternalUrl,
+		YoutubeURL:  p.YoutubeUrl,
+		Attributes:  []*model.ProjectAttribute{},
+		Contracts:   []*model.ProjectContract{},
+		Funding:     &model.ProjectFunding{Value: leaderboard.FormatAmount(funding.Value.Hex()), Funders: int(funding.Funders)},
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, a := range p.Attributes {
+		res.Attributes = append(res.Attributes, &model.ProjectAttribute{TraitType: a.TraitType, DisplayType: a.DisplayType, Value: a.Value})
+	}
+	for _, c := range contracts {
+		res.Contracts = append(res.Contracts, &model.ProjectContract{Kind: c.Kind, Name: c.Name, Address: c.Address})
+	}
+	return res
+}
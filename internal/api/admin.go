@@ -0,0 +1,203 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type excludeWalletRequest struct {
+	WalletAddress string `json:"wallet_address"`
+	Reason        string `json:"reason"`
+}
+
+type createGrantRequest struct {
+	WalletAddress string `json:"wallet_address"`
+	Points        int64  `json:"points"`
+	Reason        string `json:"reason"`
+	// RFC3339, grant never expires when empty
+	ExpiresAt string `json:"expires_at"`
+}
+
+// Operator recorded on grants and exclusions is the subject of the admin api key or token
+func AdminHandlers(e *echo.Echo, db *gorm.DB) {
+	g := e.Group("/admin", RequireRole(auth.RoleAdmin))
+
+	g.GET("/grants", func(c echo.Context) error {
+		wallet := c.QueryParam("wallet_address")
+		if wallet != "" {
+			address, err := starknet.NewAddress(wallet)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+					Error:  err.Error(),
+					Reason: "invalid wallet address",
+				})
+			}
+			wallet = address.String()
+		}
+		grants, err := leaderboard.GetGrants(db.WithContext(c.Request().Context()), wallet)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to get grants",
+			})
+		}
+		return c.JSON(http.StatusOK, grants)
+	})
+
+	g.POST("/grants", func(c echo.Context) error {
+		var req createGrantRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid grant request",
+			})
+		}
+		wallet, err := starknet.NewAddress(req.WalletAddress)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid grant request",
+			})
+		}
+		var expiresAt *time.Time
+		if req.ExpiresAt != "" {
+			t, err := time.Parse(time.RFC3339, req.ExpiresAt)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+					Error:  err.Error(),
+					Reason: "invalid grant expiry",
+				})
+			}
+			expiresAt = &t
+		}
+
+		grant, err := leaderboard.CreateGrant(db.WithContext(c.Request().Context()), wallet.String(), req.Points, req.Reason, principal(c).Subject, expiresAt)
+		if errors.Is(err, leaderboard.ErrInvalidGrant) || errors.Is(err, leaderboard.ErrGrantExpiryInPast) {
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid grant request",
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to create grant",
+			})
+		}
+		return c.JSON(http.StatusCreated, grant)
+	})
+
+	g.DELETE("/grants/:id", func(c echo.Context) error {
+		grant, err := leaderboard.RevokeGrant(db.WithContext(c.Request().Context()), c.Param("id"), principal(c).Subject)
+		switch {
+		case errors.Is(err, leaderboard.ErrGrantNotFound):
+			return c.JSON(http.StatusNotFound, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "grant not found",
+			})
+		case errors.Is(err, leaderboard.ErrGrantAlreadyRevoked):
+			return c.JSON(http.StatusConflict, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "grant already revoked",
+			})
+		case err != nil:
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to revoke grant",
+			})
+		}
+		return c.JSON(http.StatusOK, grant)
+	})
+	g.GET("/exclusions", func(c echo.Context) error {
+		exclusions, err := leaderboard.GetExclusions(db.WithContext(c.Request().Context()))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to get exclusions",
+			})
+		}
+		return c.JSON(http.StatusOK, exclusions)
+	})
+
+	g.POST("/exclusions", func(c echo.Context) error {
+		var req excludeWalletRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid exclusion request",
+			})
+		}
+		exclusion, err := leaderboard.ExcludeWallet(db.WithContext(c.Request().Context()), req.WalletAddress, req.Reason, principal(c).Subject)
+		if errors.Is(err, leaderboard.ErrInvalidExclusion) {
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid exclusion request",
+			})
+		}
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to exclude wallet",
+			})
+		}
+		return c.JSON(http.StatusCreated, exclusion)
+	})
+
+	g.DELETE("/exclusions/:wallet", func(c echo.Context) error {
+		err := leaderboard.IncludeWallet(db.WithContext(c.Request().Context()), c.Param("wallet"))
+		switch {
+		case errors.Is(err, leaderboard.ErrInvalidExclusion):
+			return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "invalid wallet address",
+			})
+		case errors.Is(err, leaderboard.ErrExclusionNotFound):
+			return c.JSON(http.StatusNotFound, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "exclusion not found",
+			})
+		case errors.Is(err, leaderboard.ErrExclusionManaged):
+			return c.JSON(http.StatusConflict, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "exclusion has to be removed from configuration",
+			})
+		case err != nil:
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to remove exclusion",
+			})
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	// Wallets flagged by anti-sybil heuristics, to be reviewed before being excluded
+	g.GET("/review", func(c echo.Context) error {
+		minFunded := leaderboard.DefaultMinFundedWallets
+		if v := c.QueryParam("min_funded"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 2 {
+				return c.JSON(http.StatusBadRequest, ApiErrorResponse{
+					Error:  "min_funded must be a number greater than 1",
+					Reason: "invalid review request",
+				})
+			}
+			minFunded = n
+		}
+		flags, err := leaderboard.ReviewWallets(db.WithContext(c.Request().Context()), minFunded)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ApiErrorResponse{
+				Error:  err.Error(),
+				Reason: "failed to review wallets",
+			})
+		}
+		return c.JSON(http.StatusOK, flags)
+	})
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"context"
 	"net/http"
+	"os"
 
+	"github.com/carbonable/leaderboard/internal/auth"
 	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -15,19 +19,43 @@ type ApiErrorResponse struct {
 	Reason string
 }
 
-func Run(storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient) {
+func Run(storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient, rules *leaderboard.ScoringRules, network starknet.StarknetNetwork) {
+	// NOTE: production disables introspection and playground
+	production := os.Getenv("APP_ENV") == "production"
+	origins := OriginsFromString(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	authenticator := auth.NewAuthenticator(
+		auth.WithApiKeys(auth.KeysFromString(os.Getenv("PARTNER_API_KEYS"), auth.RolePartner)),
+		auth.WithApiKeys(auth.KeysFromString(os.Getenv("ADMIN_API_KEYS"), auth.RoleAdmin)),
+		auth.WithJwtSecret(os.Getenv("JWT_SECRET")),
+	)
+
 	e := echo.New()
+	ipExtractor, err := IPExtractorFromString(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	e.IPExtractor = ipExtractor
 
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
-	e.Use(middleware.CORS())
+	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		AllowOrigins: origins,
+	}))
+	e.Use(RateLimitMiddleware())
+	e.Use(AuthMiddleware(authenticator))
+	e.Use(RequestTimeout(requestTimeout))
 
 	e.GET("/ping", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Pong !")
 	})
 
-	GraphqlHandlers(e, storage, db, rpc)
+	updates := leaderboard.NewUpdateBroker()
+	go leaderboard.RunUpdatesListener(context.Background(), os.Getenv("DATABASE_URL"), db, updates)
+
+	GraphqlHandlers(e, storage, db, rpc, rules, network, updates, authenticator, origins, production)
 	StarknetHandlers(e, storage, db, rpc)
+	V1Handlers(e, storage, db)
+	AdminHandlers(e, db)
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
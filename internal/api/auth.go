@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	principalContextKey = "principal"
+	apiKeyHeader        = "X-Api-Key"
+)
+
+// Authenticate every request from its api key or bearer token, invalid credentials are refused
+// instead of falling back to public role
+func AuthMiddleware(a *auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, _ := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			p, err := a.Authenticate(c.Request().Header.Get(apiKeyHeader), token)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, ApiErrorResponse{
+					Error:  err.Error(),
+					Reason: "invalid api key or token",
+				})
+			}
+			c.Set(principalContextKey, p)
+			c.SetRequest(c.Request().WithContext(auth.WithPrincipal(c.Request().Context(), p)))
+			return next(c)
+		}
+	}
+}
+
+func principal(c echo.Context) auth.Principal {
+	if p, ok := c.Get(principalContextKey).(auth.Principal); ok {
+		return p
+	}
+	return auth.Anonymous
+}
+
+func RequireRole(role auth.Role) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			p := principal(c)
+			if p.Role.Includes(role) {
+				return next(c)
+			}
+			status := http.StatusForbidden
+			if p.Role == auth.RolePublic {
+				status = http.StatusUnauthorized
+			}
+			return c.JSON(status, ApiErrorResponse{
+				Error:  "missing role " + string(role),
+				Reason: "not allowed",
+			})
+		}
+	}
+}
+
+// Parse allowed origins formatted as "https://a.io,https://b.io", every origin is allowed when empty
+func OriginsFromString(s string) []string {
+	var origins []string
+	for _, o := range strings.Split(s, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	if len(origins) == 0 {
+		return []string{"*"}
+	}
+	return origins
+}
+
+func originAllowed(origins []string, origin string) bool {
+	for _, o := range origins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"gotest.tools/assert"
+)
+
+const (
+	adminKey  = "admin-key"
+	jwtSecret = "changemebro"
+)
+
+func bearer(t *testing.T, role string) string {
+	claims := auth.Claims{StandardClaims: jwt.StandardClaims{Subject: "alice", ExpiresAt: time.Now().Add(time.Hour).Unix()}, Role: role}
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(jwtSecret))
+	assert.NilError(t, err)
+	return s
+}
+
+func newAuthServer() *echo.Echo {
+	e := echo.New()
+	e.Use(AuthMiddleware(auth.NewAuthenticator(
+		auth.WithApiKeys(auth.KeysFromString("partner:"+partnerKey, auth.RolePartner)),
+		auth.WithApiKeys(auth.KeysFromString("admin:"+adminKey, auth.RoleAdmin)),
+		auth.WithJwtSecret(jwtSecret),
+	)))
+	ok := func(c echo.Context) error {
+		return c.String(http.StatusOK, principal(c).Subject)
+	}
+	e.GET("/public", ok)
+	e.GET("/partner", ok, RequireRole(auth.RolePartner))
+	e.GET("/admin", ok, RequireRole(auth.RoleAdmin))
+	return e
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	e := newAuthServer()
+
+	tests := []struct {
+		name    string
+		target  string
+		apiKey  string
+		token   string
+		status  int
+		subject string
+	}{
+		{name: "anonymous on public route", target: "/public", status: http.StatusOK},
+		{name: "invalid key on public route", target: "/public", apiKey: "nope", status: http.StatusUnauthorized},
+		{name: "invalid token on public route", target: "/public", token: "nope", status: http.StatusUnauthorized},
+		{name: "anonymous on partner route", target: "/partner", status: http.StatusUnauthorized},
+		{name: "partner key", target: "/partner", apiKey: partnerKey, status: http.StatusOK, subject: "partner"},
+		{name: "partner key on admin route", target: "/admin", apiKey: partnerKey, status: http.StatusForbidden},
+		{name: "admin key on partner route", target: "/partner", apiKey: adminKey, status: http.StatusOK, subject: "admin"},
+		{name: "partner token", target: "/partner", token: bearer(t, "partner"), status: http.StatusOK, subject: "alice"},
+		{name: "partner token on admin route", target: "/admin", token: bearer(t, "partner"), status: http.StatusForbidden},
+		{name: "key wins over token", target: "/admin", apiKey: partnerKey, token: bearer(t, "admin"), status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			if tt.token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, rec.Code, tt.status, rec.Body.String())
+			if tt.subject != "" {
+				assert.Equal(t, rec.Body.String(), tt.subject)
+			}
+		})
+	}
+}
+
+func TestOriginsFromString(t *testing.T) {
+	assert.DeepEqual(t, OriginsFromString(""), []string{"*"})
+	assert.DeepEqual(t, OriginsFromString(" https://a.io, ,https://b.io"), []string{"https://a.io", "https://b.io"})
+	assert.Assert(t, originAllowed([]string{"https://a.io"}, "https://a.io"))
+	assert.Assert(t, !originAllowed([]string{"https://a.io"}, "https://b.io"))
+}
@@ -1,30 +1,88 @@
 package api
 
 import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/carbonable/leaderboard/graph"
+	"github.com/carbonable/leaderboard/internal/auth"
 	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
-func GraphqlHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient) {
-	graphqlHandler := handler.NewDefaultServer(
+// Introspection and playground are disabled in production
+func GraphqlHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient, rules *leaderboard.ScoringRules, network starknet.StarknetNetwork, updates *leaderboard.UpdateBroker, authenticator *auth.Authenticator, origins []string, production bool) {
+	graphqlHandler := handler.New(
 		graph.NewExecutableSchema(
-			graph.Config{Resolvers: graph.NewGraphResolver(storage, db, rpc)},
+			graph.Config{
+				Resolvers:  graph.NewGraphResolver(storage, db, rpc, rules, network, updates),
+				Complexity: graph.NewComplexity(),
+			},
 		),
 	)
-	graphqlHandler.Use(extension.Introspection{})
-	playgroundHandler := playground.Handler("GraphQL", "/query")
+	// NOTE: origins are checked on websocket upgrade the same way CORS does on http transports
+	graphqlHandler.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || originAllowed(origins, origin)
+			},
+		},
+		// browsers cannot set headers on upgrade, credentials can be given in connection_init payload instead
+		InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			apiKey, _ := payload[apiKeyHeader].(string)
+			token, _ := strings.CutPrefix(payload.Authorization(), "Bearer ")
+			if apiKey == "" && token == "" {
+				return ctx, nil, nil
+			}
+			p, err := authenticator.Authenticate(apiKey, token)
+			if err != nil {
+				return ctx, nil, err
+			}
+			return auth.WithPrincipal(ctx, p), nil, nil
+		},
+	})
+	graphqlHandler.AddTransport(transport.Options{})
+	graphqlHandler.AddTransport(transport.GET{})
+	graphqlHandler.AddTransport(transport.POST{})
+	graphqlHandler.AddTransport(transport.MultipartForm{})
+	graphqlHandler.SetQueryCache(lru.New(1000))
+	if !production {
+		graphqlHandler.Use(extension.Introspection{})
+	}
+	graphqlHandler.Use(extension.FixedComplexityLimit(maxQueryComplexity))
+	graphqlHandler.Use(depthLimit{max: maxQueryDepth})
+	// clients can send query hash only once query has been persisted
+	graphqlHandler.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(1000),
+	})
 
 	e.POST("/query", func(c echo.Context) error {
 		graphqlHandler.ServeHTTP(c.Response(), c.Request())
 		return nil
 	})
+	// websocket upgrade for subscriptions
+	e.GET("/query", func(c echo.Context) error {
+		graphqlHandler.ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
 
+	if production {
+		return
+	}
+	playgroundHandler := playground.Handler("GraphQL", "/query")
 	e.GET("/playground", func(c echo.Context) error {
 		playgroundHandler.ServeHTTP(c.Response(), c.Request())
 		return nil
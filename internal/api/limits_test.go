@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"gotest.tools/assert"
+)
+
+func TestIPExtractorFromString(t *testing.T) {
+	testCases := []struct {
+		name     string
+		proxies  string
+		remote   string
+		xff      string
+		expected string
+	}{
+		{name: "no proxy", remote: "10.0.0.1:1234", xff: "1.2.3.4", expected: "10.0.0.1"},
+		{name: "trusted proxy", proxies: "10.0.0.0/8", remote: "10.0.0.1:1234", xff: "1.2.3.4", expected: "1.2.3.4"},
+		{name: "untrusted proxy", proxies: "10.0.0.0/8", remote: "5.6.7.8:1234", xff: "1.2.3.4", expected: "5.6.7.8"},
+		{name: "spoofed header behind proxy", proxies: "10.0.0.0/8", remote: "10.0.0.1:1234", xff: "9.9.9.9, 1.2.3.4", expected: "1.2.3.4"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			extract, err := IPExtractorFromString(tc.proxies)
+			assert.NilError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remote
+			req.Header.Set("X-Forwarded-For", tc.xff)
+			assert.Equal(t, extract(req), tc.expected)
+		})
+	}
+
+	_, err := IPExtractorFromString("10.0.0.0")
+	assert.ErrorContains(t, err, "invalid CIDR")
+}
+
+func TestRequestTimeout(t *testing.T) {
+	testCases := []struct {
+		name     string
+		upgrade  string
+		deadline bool
+	}{
+		{name: "http request", deadline: true},
+		{name: "websocket upgrade", upgrade: "websocket", deadline: false},
+		{name: "websocket upgrade in other case", upgrade: "WebSocket", deadline: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.upgrade != "" {
+				req.Header.Set(echo.HeaderUpgrade, tc.upgrade)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			var deadline bool
+			err := RequestTimeout(time.Second)(func(c echo.Context) error {
+				_, deadline = c.Request().Context().Deadline()
+				return nil
+			})(c)
+			assert.NilError(t, err)
+			assert.Equal(t, deadline, tc.deadline)
+		})
+	}
+}
+
+func TestSelectionDepth(t *testing.T) {
+	schema := gqlparser.MustLoadSchema(&ast.Source{Input: `
+		type Query { wallet: Wallet }
+		type Wallet { address: String referrer: Wallet }
+	`})
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "flat", query: `{ wallet { address } }`, expected: 2},
+		{name: "nested", query: `{ wallet { referrer { referrer { address } } } }`, expected: 4},
+		{name: "inline fragment", query: `{ wallet { ... on Wallet { referrer { address } } } }`, expected: 3},
+		{name: "fragment spread", query: `{ wallet { ...Referrer } } fragment Referrer on Wallet { referrer { referrer { address } } }`, expected: 4},
+		{name: "introspection skipped", query: `{ __schema { types { fields { type { name } } } } wallet { address } }`, expected: 2},
+		{name: "typename skipped", query: `{ __typename }`, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tc.query)
+			assert.Equal(t, len(errs), 0, errs.Error())
+			assert.Equal(t, selectionDepth(doc.Operations[0].SelectionSet), tc.expected)
+		})
+	}
+}
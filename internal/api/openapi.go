@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/labstack/echo/v4"
+)
+
+type openapiParam struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Required    bool
+	// Parameter can be repeated in query string
+	Repeated bool
+}
+
+// Description of a route, response is a zero value of the type returned on success.
+// Route is public when role is empty
+type openapiOperation struct {
+	Summary  string
+	Params   []openapiParam
+	Response any
+	Role     auth.Role
+}
+
+// Routes of a group along with the OpenAPI document describing them
+type documentedGroup struct {
+	group  *echo.Group
+	prefix string
+	title  string
+	paths  map[string]map[string]any
+	// Schemas of response types, indexed by type name
+	schemas map[string]any
+}
+
+func newDocumentedGroup(e *echo.Echo, prefix string, title string) *documentedGroup {
+	return &documentedGroup{
+		group:   e.Group(prefix),
+		prefix:  prefix,
+		title:   title,
+		paths:   make(map[string]map[string]any),
+		schemas: map[string]any{"ApiErrorResponse": schemaOf(reflect.TypeOf(ApiErrorResponse{}), nil)},
+	}
+}
+
+var pathParamRegexp = regexp.MustCompile(`:([a-z_]+)`)
+
+func (d *documentedGroup) GET(path string, op openapiOperation, h echo.HandlerFunc) {
+	var m []echo.MiddlewareFunc
+	if op.Role != "" {
+		m = append(m, RequireRole(op.Role))
+	}
+	d.group.GET(path, h, m...)
+
+	var params []any
+	for _, p := range op.Params {
+		schema := map[string]any{"type": p.Type}
+		if p.Repeated {
+			schema = map[string]any{"type": "array", "items": schema}
+		}
+		param := map[string]any{"name": p.Name, "in": p.In, "required": p.Required || p.In == "path", "schema": schema}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+	errorResponse := map[string]any{
+		"description": "error",
+		"content":     map[string]any{"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/ApiErrorResponse"}}},
+	}
+	operation := map[string]any{
+		"summary":    op.Summary,
+		"parameters": params,
+		"responses": map[string]any{
+			"200": map[string]any{
+				"description": "success",
+				"content":     map[string]any{"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(op.Response), d.schemas)}},
+			},
+			"default": errorResponse,
+		},
+	}
+	if op.Role != "" {
+		operation["description"] = "requires " + string(op.Role) + " role"
+		operation["security"] = []any{map[string]any{"apiKey": []any{}}, map[string]any{"bearer": []any{}}}
+	}
+
+	openapiPath := d.prefix + pathParamRegexp.ReplaceAllString(path, "{$1}")
+	if _, exists := d.paths[openapiPath]; !exists {
+		d.paths[openapiPath] = make(map[string]any)
+	}
+	d.paths[openapiPath]["get"] = operation
+}
+
+func (d *documentedGroup) Document() map[string]any {
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": d.title, "version": strings.TrimPrefix(d.prefix, "/")},
+		"paths":   d.paths,
+		"components": map[string]any{
+			"schemas": d.schemas,
+			"securitySchemes": map[string]any{
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// Serve document of every route registered so far
+func (d *documentedGroup) ServeDocument(path string) {
+	d.group.GET(path, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, d.Document())
+	})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// JSON schema of a type as encoding/json writes it. Named structs are added to components
+// when components are given and referenced from there
+func schemaOf(t reflect.Type, components map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), components)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), components)}
+	case reflect.Struct:
+		// NOTE: generic type names are not valid component names
+		name := t.Name()
+		if components != nil && name != "" && !strings.Contains(name, "[") {
+			if _, exists := components[name]; !exists {
+				components[name] = map[string]any{}
+				components[name] = structSchema(t, components)
+			}
+			return map[string]any{"$ref": "#/components/schemas/" + name}
+		}
+		return structSchema(t, components)
+	}
+	return map[string]any{}
+}
+
+func structSchema(t reflect.Type, components map[string]any) map[string]any {
+	properties := make(map[string]any)
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = schemaOf(f.Type, components)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
@@ -7,13 +7,18 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/carbonable/leaderboard/internal/auth"
 	"github.com/carbonable/leaderboard/internal/indexer"
 	"github.com/carbonable/leaderboard/internal/starknet"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
 
+// Deprecated: unversioned routes kept for existing clients, use the /v1 api instead
 func StarknetHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc starknet.StarknetRpcClient) {
+	// raw indexer data is restricted to partners
+	partner := RequireRole(auth.RolePartner)
+
 	e.GET("/latest-block", func(c echo.Context) error {
 		res := storage.Get([]byte("LATEST_BLOCK"))
 
@@ -32,7 +37,7 @@ func StarknetHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc st
 		return c.JSON(200, struct{ BlockNumber uint64 }{
 			BlockNumber: num,
 		})
-	})
+	}, partner)
 
 	e.GET("/block/:number", func(c echo.Context) error {
 		number, err := strconv.ParseUint(c.Param("number"), 10, 64)
@@ -66,7 +71,7 @@ func StarknetHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc st
 		return c.JSON(http.StatusOK, struct{ Block starknet.GetBlockResponse }{
 			Block: resp,
 		})
-	})
+	}, partner)
 
 	e.GET("/contract/:hash", func(c echo.Context) error {
 		encodedTxs := storage.Scan([]byte(c.Param("hash") + "#TX#"))
@@ -81,7 +86,7 @@ func StarknetHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc st
 			Txs:    txs,
 			Events: events,
 		})
-	})
+	}, partner)
 
 	e.GET("/contract-idx/:address", func(c echo.Context) error {
 		address := c.Param("address")
@@ -105,5 +110,5 @@ func StarknetHandlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB, rpc st
 			Error:  "contract index not found",
 			Reason: "contract index not found",
 		})
-	})
+	}, partner)
 }
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const defaultPageSize = 50
+
+var (
+	errBlockNotIndexed = errors.New("block not indexed")
+	errInvalidCursor   = errors.New("invalid cursor")
+)
+
+// Paginated response, next_cursor is empty on last page
+type page[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type BlockNumberResponse struct {
+	BlockNumber uint64 `json:"block_number"`
+}
+
+type ContractIndexResponse struct {
+	Blocks      []uint64 `json:"blocks"`
+	LatestBlock uint64   `json:"latest_block"`
+}
+
+type EventResponse struct {
+	RecordedAt      time.Time         `json:"recorded_at"`
+	EventId         string            `json:"event_id"`
+	TxHash          string            `json:"tx_hash"`
+	EventName       string            `json:"event_name"`
+	ContractAddress string            `json:"contract_address"`
+	WalletAddress   string            `json:"wallet_address"`
+	Data            map[string]string `json:"data"`
+	Metadata        map[string]string `json:"metadata"`
+}
+
+func eventResponse(e leaderboard.DomainEvent) EventResponse {
+	return EventResponse{
+		RecordedAt:      e.RecordedAt,
+		EventId:         e.EventId,
+		TxHash:          leaderboard.TxHashFromEventId(e.EventId),
+		EventName:       e.EventName,
+		ContractAddress: e.FromAddress,
+		WalletAddress:   e.WalletAddress,
+		Data:            e.Data,
+		Metadata:        e.Metadata,
+	}
+}
+
+func apiError(c echo.Context, status int, err error, reason string) error {
+	return c.JSON(status, ApiErrorResponse{
+		Error:  err.Error(),
+		Reason: reason,
+	})
+}
+
+func pageSize(c echo.Context) (int, error) {
+	limit := c.QueryParam("limit")
+	if limit == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > leaderboard.MaxPageSize {
+		return 0, leaderboard.ErrInvalidPageSize
+	}
+	return n, nil
+}
+
+func decodeBlock(storage indexer.Storage, number uint64) (*starknet.GetBlockResponse, error) {
+	key := []byte("BLOCK#" + strconv.FormatUint(number, 10))
+	if !storage.Has(key) {
+		return nil, errBlockNotIndexed
+	}
+	var block starknet.GetBlockResponse
+	if err := gob.NewDecoder(bytes.NewBuffer(storage.Get(key))).Decode(&block); err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+// Optional block number query param
+func blockParam(c echo.Context, name string) (*uint64, error) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+var (
+	limitParam  = openapiParam{Name: "limit", In: "query", Type: "integer", Description: fmt.Sprintf("page size, %d by default, at most %d", defaultPageSize, leaderboard.MaxPageSize)}
+	cursorParam = openapiParam{Name: "cursor", In: "query", Type: "string", Description: "next_cursor of previous page"}
+)
+
+// Versioned read api over indexed blocks and contract events, documented at /v1/openapi.json.
+// Indexer data is restricted to partners, document is public
+func V1Handlers(e *echo.Echo, storage indexer.Storage, db *gorm.DB) {
+	v1 := newDocumentedGroup(e, "/v1", "Carbonable leaderboard indexer api")
+
+	v1.GET("/blocks/latest", openapiOperation{
+		Summary:  "Latest indexed block",
+		Role:     auth.RolePartner,
+		Response: BlockNumberResponse{},
+	}, func(c echo.Context) error {
+		var bn string
+		if err := gob.NewDecoder(bytes.NewBuffer(storage.Get([]byte("LATEST_BLOCK")))).Decode(&bn); err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to decode latest block")
+		}
+		num, err := strconv.ParseUint(bn, 10, 64)
+		if err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to decode latest block")
+		}
+		return c.JSON(http.StatusOK, BlockNumberResponse{BlockNumber: num})
+	})
+
+	v1.GET("/blocks/:number", openapiOperation{
+		Summary:  "Indexed block",
+		Role:     auth.RolePartner,
+		Params:   []openapiParam{{Name: "number", In: "path", Type: "integer"}},
+		Response: starknet.GetBlockResponse{},
+	}, func(c echo.Context) error {
+		number, err := strconv.ParseUint(c.Param("number"), 10, 64)
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid block number")
+		}
+		block, err := decodeBlock(storage, number)
+		if errors.Is(err, errBlockNotIndexed) {
+			return apiError(c, http.StatusNotFound, err, "block not found")
+		}
+		if err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to decode block")
+		}
+		return c.JSON(http.StatusOK, block)
+	})
+
+	v1.GET("/contracts/:address/index", openapiOperation{
+		Summary:  "Blocks where contract emitted events or sent transactions",
+		Role:     auth.RolePartner,
+		Params:   []openapiParam{{Name: "address", In: "path", Type: "string"}},
+		Response: ContractIndexResponse{},
+	}, func(c echo.Context) error {
+		address, err := starknet.NewAddress(c.Param("address"))
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid contract address")
+		}
+		key := []byte("IDX#" + address.Padded())
+		if !storage.Has(key) {
+			return apiError(c, http.StatusNotFound, errors.New("contract index not found"), "contract is not indexed")
+		}
+		idx := indexer.NewContractIndex(0)
+		if err := idx.Decode(storage.Get(key)); err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to decode contract index")
+		}
+		return c.JSON(http.StatusOK, ContractIndexResponse{Blocks: idx.Blocks, LatestBlock: idx.LatestBlock})
+	})
+
+	v1.GET("/contracts/:address/events", openapiOperation{
+		Summary: "Events of contract, newest first",
+		Role:    auth.RolePartner,
+		Params: []openapiParam{
+			{Name: "address", In: "path", Type: "string"},
+			{Name: "event_name", In: "query", Type: "string", Repeated: true, Description: "domain event name, minter:buy for instance"},
+			{Name: "from_block", In: "query", Type: "integer", Description: "included"},
+			{Name: "to_block", In: "query", Type: "integer", Description: "included"},
+			limitParam,
+			cursorParam,
+		},
+		Response: page[EventResponse]{},
+	}, func(c echo.Context) error {
+		address, err := starknet.NewAddress(c.Param("address"))
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid contract address")
+		}
+		limit, err := pageSize(c)
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid limit")
+		}
+		var after *leaderboard.ActivityCursor
+		if cursor := c.QueryParam("cursor"); cursor != "" {
+			if after, err = leaderboard.DecodeActivityCursor(cursor); err != nil {
+				return apiError(c, http.StatusBadRequest, err, "invalid cursor")
+			}
+		}
+		fromBlock, err := blockParam(c, "from_block")
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid from_block")
+		}
+		toBlock, err := blockParam(c, "to_block")
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid to_block")
+		}
+
+		filter := leaderboard.ContractEventFilter{EventNames: c.QueryParams()["event_name"], FromBlock: fromBlock, ToBlock: toBlock}
+		events, hasNext, err := leaderboard.GetContractEvents(db.WithContext(c.Request().Context()), address.String(), filter, limit, after)
+		if err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to get contract events")
+		}
+		res := page[EventResponse]{Data: []EventResponse{}}
+		for _, e := range events {
+			res.Data = append(res.Data, eventResponse(e))
+		}
+		if hasNext {
+			last := events[len(events)-1]
+			res.NextCursor = leaderboard.ActivityCursor{RecordedAt: last.RecordedAt, ID: last.ID}.Encode()
+		}
+		return c.JSON(http.StatusOK, res)
+	})
+
+	v1.GET("/contracts/:address/transactions", openapiOperation{
+		Summary:  "Transactions sent by contract, ordered by hash",
+		Role:     auth.RolePartner,
+		Params:   []openapiParam{{Name: "address", In: "path", Type: "string"}, limitParam, cursorParam},
+		Response: page[starknet.Transaction]{},
+	}, func(c echo.Context) error {
+		scanner, ok := storage.(indexer.PageScanner)
+		if !ok {
+			return apiError(c, http.StatusNotImplemented, errors.New("storage cannot be paginated"), "transactions are not available")
+		}
+		address, err := starknet.NewAddress(c.Param("address"))
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid contract address")
+		}
+		limit, err := pageSize(c)
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, err, "invalid limit")
+		}
+		after, err := base64.RawURLEncoding.DecodeString(c.QueryParam("cursor"))
+		if err != nil {
+			return apiError(c, http.StatusBadRequest, errInvalidCursor, "invalid cursor")
+		}
+
+		entries, err := scanner.ScanPage([]byte(address.Padded()+"#TX#"), after, limit+1)
+		if err != nil {
+			return apiError(c, http.StatusInternalServerError, err, "failed to get contract transactions")
+		}
+		res := page[starknet.Transaction]{Data: []starknet.Transaction{}}
+		if len(entries) > limit {
+			entries = entries[:limit]
+			res.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(entries[limit-1].ID))
+		}
+		for _, entry := range entries {
+			var tx starknet.Transaction
+			if err := gob.NewDecoder(bytes.NewBuffer(entry.Value)).Decode(&tx); err != nil {
+				return apiError(c, http.StatusInternalServerError, err, "failed to decode transaction")
+			}
+			res.Data = append(res.Data, tx)
+		}
+		return c.JSON(http.StatusOK, res)
+	})
+
+	v1.ServeDocument("/openapi.json")
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/labstack/echo/v4"
+	"gotest.tools/assert"
+)
+
+const partnerKey = "partner-key"
+
+// In memory storage able to page through a prefix
+type memStorage map[string][]byte
+
+func (s memStorage) Get(id []byte) []byte {
+	return s[string(id)]
+}
+
+func (s memStorage) Has(id []byte) bool {
+	_, exists := s[string(id)]
+	return exists
+}
+
+func (s memStorage) Set(key []byte, value []byte) error {
+	s[string(key)] = value
+	return nil
+}
+
+func (s memStorage) Scan(prefix []byte) [][]byte {
+	var values [][]byte
+	for _, kv := range s.sorted(prefix, nil) {
+		values = append(values, kv.Value)
+	}
+	return values
+}
+
+func (s memStorage) ScanPage(prefix []byte, after []byte, limit int) ([]indexer.KVStore, error) {
+	entries := s.sorted(prefix, after)
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s memStorage) sorted(prefix []byte, after []byte) []indexer.KVStore {
+	var entries []indexer.KVStore
+	for k, v := range s {
+		if strings.HasPrefix(k, string(prefix)) && k > string(after) {
+			entries = append(entries, indexer.KVStore{ID: k, Value: v})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+func encode(t *testing.T, v any) []byte {
+	var buf bytes.Buffer
+	assert.NilError(t, gob.NewEncoder(&buf).Encode(v))
+	return buf.Bytes()
+}
+
+func newV1Server(t *testing.T) (*echo.Echo, memStorage) {
+	storage := memStorage{}
+	storage.Set([]byte("LATEST_BLOCK"), encode(t, "42"))
+	storage.Set([]byte("BLOCK#42"), encode(t, starknet.GetBlockResponse{BlockNumber: 42, Timestamp: 1700000000}))
+
+	idx := indexer.NewContractIndex(40)
+	idx.AddBlock(41)
+	buf, err := idx.Encode()
+	assert.NilError(t, err)
+	storage.Set([]byte("IDX#"+starknet.Address("0x2").Padded()), buf.Bytes())
+
+	for _, hash := range []string{"0xa", "0xb", "0xc"} {
+		storage.Set([]byte(starknet.Address("0x2").Padded()+"#TX#"+hash), encode(t, starknet.Transaction{TransactionHash: hash}))
+	}
+
+	e := echo.New()
+	e.Use(AuthMiddleware(auth.NewAuthenticator(auth.WithApiKeys(auth.KeysFromString("partner:"+partnerKey, auth.RolePartner)))))
+	// NOTE: every tested route returns before querying events
+	V1Handlers(e, storage, nil)
+	return e, storage
+}
+
+func get(e *echo.Echo, target string, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if apiKey != "" {
+		req.Header.Set(apiKeyHeader, apiKey)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestV1Status(t *testing.T) {
+	e, _ := newV1Server(t)
+
+	tests := []struct {
+		name   string
+		target string
+		apiKey string
+		status int
+	}{
+		{name: "anonymous", target: "/v1/blocks/42", status: http.StatusUnauthorized},
+		{name: "invalid key", target: "/v1/blocks/42", apiKey: "nope", status: http.StatusUnauthorized},
+		{name: "public document", target: "/v1/openapi.json", status: http.StatusOK},
+		{name: "latest block", target: "/v1/blocks/latest", apiKey: partnerKey, status: http.StatusOK},
+		{name: "block", target: "/v1/blocks/42", apiKey: partnerKey, status: http.StatusOK},
+		{name: "unknown block", target: "/v1/blocks/43", apiKey: partnerKey, status: http.StatusNotFound},
+		{name: "invalid block", target: "/v1/blocks/abc", apiKey: partnerKey, status: http.StatusBadRequest},
+		{name: "contract index", target: "/v1/contracts/0x2/index", apiKey: partnerKey, status: http.StatusOK},
+		{name: "unknown contract index", target: "/v1/contracts/0x3/index", apiKey: partnerKey, status: http.StatusNotFound},
+		{name: "events limit too large", target: "/v1/contracts/0x2/events?limit=1000", apiKey: partnerKey, status: http.StatusBadRequest},
+		{name: "events invalid block range", target: "/v1/contracts/0x2/events?from_block=abc", apiKey: partnerKey, status: http.StatusBadRequest},
+		{name: "events invalid to block", target: "/v1/contracts/0x2/events?to_block=-1", apiKey: partnerKey, status: http.StatusBadRequest},
+		{name: "transactions invalid cursor", target: "/v1/contracts/0x2/transactions?cursor=%25", apiKey: partnerKey, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(e, tt.target, tt.apiKey)
+			assert.Equal(t, rec.Code, tt.status, rec.Body.String())
+		})
+	}
+}
+
+func TestV1Blocks(t *testing.T) {
+	e, _ := newV1Server(t)
+
+	var latest BlockNumberResponse
+	assert.NilError(t, json.Unmarshal(get(e, "/v1/blocks/latest", partnerKey).Body.Bytes(), &latest))
+	assert.Equal(t, latest.BlockNumber, uint64(42))
+
+	var block starknet.GetBlockResponse
+	assert.NilError(t, json.Unmarshal(get(e, "/v1/blocks/42", partnerKey).Body.Bytes(), &block))
+	assert.Equal(t, block.Timestamp, uint64(1700000000))
+
+	var idx ContractIndexResponse
+	assert.NilError(t, json.Unmarshal(get(e, "/v1/contracts/0x2/index", partnerKey).Body.Bytes(), &idx))
+	assert.DeepEqual(t, idx, ContractIndexResponse{Blocks: []uint64{41}, LatestBlock: 40})
+}
+
+func TestV1TransactionsPagination(t *testing.T) {
+	e, _ := newV1Server(t)
+
+	var first page[starknet.Transaction]
+	assert.NilError(t, json.Unmarshal(get(e, "/v1/contracts/0x2/transactions?limit=2", partnerKey).Body.Bytes(), &first))
+	assert.Equal(t, len(first.Data), 2)
+	assert.Equal(t, first.Data[0].TransactionHash, "0xa")
+	assert.Assert(t, first.NextCursor != "")
+
+	var last page[starknet.Transaction]
+	assert.NilError(t, json.Unmarshal(get(e, "/v1/contracts/0x2/transactions?limit=2&cursor="+first.NextCursor, partnerKey).Body.Bytes(), &last))
+	assert.Equal(t, len(last.Data), 1)
+	assert.Equal(t, last.Data[0].TransactionHash, "0xc")
+	assert.Equal(t, last.NextCursor, "")
+}
+
+func TestBlockParam(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/?from_block=42", nil), httptest.NewRecorder())
+
+	from, err := blockParam(c, "from_block")
+	assert.NilError(t, err)
+	assert.Equal(t, *from, uint64(42))
+
+	to, err := blockParam(c, "to_block")
+	assert.NilError(t, err)
+	assert.Assert(t, to == nil)
+}
+
+type schemaItem struct {
+	Name     string            `json:"name"`
+	Optional string            `json:"optional,omitempty"`
+	Ignored  string            `json:"-"`
+	Pointer  *uint64           `json:"pointer"`
+	Tags     []string          `json:"tags"`
+	Raw      []byte            `json:"raw"`
+	Labels   map[string]string `json:"labels"`
+	At       time.Time         `json:"at"`
+	Untagged bool
+	hidden   string
+}
+
+type schemaParent struct {
+	Items []schemaItem `json:"items"`
+}
+
+func TestStructSchema(t *testing.T) {
+	schema := structSchema(reflect.TypeOf(schemaItem{}), nil)
+
+	properties := schema["properties"].(map[string]any)
+	assert.DeepEqual(t, schema["required"], []string{"Untagged", "at", "labels", "name", "raw", "tags"})
+	assert.Equal(t, len(properties), 8)
+	assert.DeepEqual(t, properties["pointer"], map[string]any{"type": "integer"})
+	assert.DeepEqual(t, properties["tags"], map[string]any{"type": "array", "items": map[string]any{"type": "string"}})
+	assert.DeepEqual(t, properties["raw"], map[string]any{"type": "string", "format": "byte"})
+	assert.DeepEqual(t, properties["labels"], map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}})
+	assert.DeepEqual(t, properties["at"], map[string]any{"type": "string", "format": "date-time"})
+	assert.DeepEqual(t, properties["Untagged"], map[string]any{"type": "boolean"})
+}
+
+func TestSchemaOfComponents(t *testing.T) {
+	components := make(map[string]any)
+	schema := schemaOf(reflect.TypeOf(schemaParent{}), components)
+
+	assert.DeepEqual(t, schema, map[string]any{"$ref": "#/components/schemas/schemaParent"})
+	assert.Equal(t, len(components), 2)
+	parent := components["schemaParent"].(map[string]any)
+	items := parent["properties"].(map[string]any)["items"]
+	assert.DeepEqual(t, items, map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/schemaItem"}})
+
+	// generic types are inlined
+	generic := schemaOf(reflect.TypeOf(page[schemaItem]{}), components)
+	assert.Equal(t, generic["type"], "object")
+	assert.Equal(t, len(components), 2)
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+type Role string
+
+const (
+	RolePublic  Role = "public"
+	RolePartner Role = "partner"
+	RoleAdmin   Role = "admin"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidRole        = errors.New("invalid role")
+)
+
+// Roles are ordered, a role is granted everything granted to lower ones
+var roleLevels = map[Role]int{
+	RolePublic:  0,
+	RolePartner: 1,
+	RoleAdmin:   2,
+}
+
+func ParseRole(s string) (Role, error) {
+	r := Role(strings.ToLower(s))
+	if _, ok := roleLevels[r]; !ok {
+		return "", ErrInvalidRole
+	}
+	return r, nil
+}
+
+func (r Role) Includes(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// Caller of the api, subject is the api key owner or the token subject
+type Principal struct {
+	Subject string
+	Role    Role
+}
+
+var Anonymous = Principal{Role: RolePublic}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// Principal of context, anonymous when request was not authenticated
+func FromContext(ctx context.Context) Principal {
+	if p, ok := ctx.Value(principalKey{}).(Principal); ok {
+		return p
+	}
+	return Anonymous
+}
+
+// Parse api keys formatted as "name:key,name:key" into principals of given role indexed by key
+func KeysFromString(s string, role Role) map[string]Principal {
+	keys := make(map[string]Principal)
+	for _, entry := range strings.Split(s, ",") {
+		name, key, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || name == "" || key == "" {
+			continue
+		}
+		keys[key] = Principal{Subject: name, Role: role}
+	}
+	return keys
+}
+
+type Claims struct {
+	jwt.StandardClaims
+	Role string `json:"role"`
+}
+
+type Authenticator struct {
+	keys      map[string]Principal
+	jwtSecret []byte
+}
+
+type AuthenticatorFunc func(*Authenticator)
+
+func NewAuthenticator(opts ...AuthenticatorFunc) *Authenticator {
+	a := &Authenticator{keys: make(map[string]Principal)}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Api keys are merged, same key given twice keeps the last principal
+func WithApiKeys(keys map[string]Principal) AuthenticatorFunc {
+	return func(a *Authenticator) {
+		for k, p := range keys {
+			a.keys[k] = p
+		}
+	}
+}
+
+// HS256 secret of bearer tokens, tokens are refused when empty
+func WithJwtSecret(secret string) AuthenticatorFunc {
+	return func(a *Authenticator) {
+		a.jwtSecret = []byte(secret)
+	}
+}
+
+// Principal owning api key or bearer token, anonymous when none is given.
+// Api key wins when both are given.
+func (a *Authenticator) Authenticate(apiKey string, token string) (Principal, error) {
+	if apiKey != "" {
+		return a.authenticateKey(apiKey)
+	}
+	if token != "" {
+		return a.authenticateToken(token)
+	}
+	return Anonymous, nil
+}
+
+func (a *Authenticator) authenticateKey(apiKey string) (Principal, error) {
+	for k, p := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(k)) == 1 {
+			return p, nil
+		}
+	}
+	return Anonymous, ErrInvalidCredentials
+}
+
+func (a *Authenticator) authenticateToken(token string) (Principal, error) {
+	if len(a.jwtSecret) == 0 {
+		return Anonymous, ErrInvalidCredentials
+	}
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodHS256 {
+			return nil, ErrInvalidCredentials
+		}
+		return a.jwtSecret, nil
+	})
+	// NOTE: jwt v3 accepts tokens without exp, they would never expire
+	if err != nil || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Anonymous, ErrInvalidCredentials
+	}
+	role, err := ParseRole(claims.Role)
+	if err != nil || claims.Subject == "" {
+		return Anonymous, ErrInvalidCredentials
+	}
+	return Principal{Subject: claims.Subject, Role: role}, nil
+}
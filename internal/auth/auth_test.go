@@ -0,0 +1,78 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/auth"
+	"github.com/golang-jwt/jwt"
+	"gotest.tools/assert"
+)
+
+const secret = "changemebro"
+
+func token(t *testing.T, method jwt.SigningMethod, key string, subject string, role string, expiresAt time.Time) string {
+	claims := auth.Claims{StandardClaims: jwt.StandardClaims{Subject: subject}, Role: role}
+	// zero date leaves exp out of the token
+	if !expiresAt.IsZero() {
+		claims.ExpiresAt = expiresAt.Unix()
+	}
+	s, err := jwt.NewWithClaims(method, claims).SignedString([]byte(key))
+	assert.NilError(t, err)
+	return s
+}
+
+func TestRoleIncludes(t *testing.T) {
+	assert.Assert(t, auth.RoleAdmin.Includes(auth.RolePartner))
+	assert.Assert(t, auth.RolePartner.Includes(auth.RolePublic))
+	assert.Assert(t, !auth.RolePublic.Includes(auth.RolePartner))
+	assert.Assert(t, !auth.RolePartner.Includes(auth.RoleAdmin))
+
+	_, err := auth.ParseRole("owner")
+	assert.Equal(t, err, auth.ErrInvalidRole)
+}
+
+func TestKeysFromString(t *testing.T) {
+	keys := auth.KeysFromString("alice:key1, bob:key2,invalid,:key3", auth.RolePartner)
+
+	assert.Equal(t, len(keys), 2)
+	assert.Equal(t, keys["key1"], auth.Principal{Subject: "alice", Role: auth.RolePartner})
+	assert.Equal(t, keys["key2"].Subject, "bob")
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := auth.NewAuthenticator(
+		auth.WithApiKeys(auth.KeysFromString("alice:admin-key", auth.RoleAdmin)),
+		auth.WithApiKeys(auth.KeysFromString("acme:partner-key", auth.RolePartner)),
+		auth.WithJwtSecret(secret),
+	)
+	inAnHour := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name     string
+		apiKey   string
+		token    string
+		expected auth.Principal
+		err      error
+	}{
+		{name: "anonymous", expected: auth.Anonymous},
+		{name: "admin key", apiKey: "admin-key", expected: auth.Principal{Subject: "alice", Role: auth.RoleAdmin}},
+		{name: "partner key", apiKey: "partner-key", expected: auth.Principal{Subject: "acme", Role: auth.RolePartner}},
+		{name: "unknown key", apiKey: "nope", expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "token", token: token(t, jwt.SigningMethodHS256, secret, "acme", "partner", inAnHour), expected: auth.Principal{Subject: "acme", Role: auth.RolePartner}},
+		{name: "expired token", token: token(t, jwt.SigningMethodHS256, secret, "acme", "partner", time.Now().Add(-time.Hour)), expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "token without expiry", token: token(t, jwt.SigningMethodHS256, secret, "acme", "partner", time.Time{}), expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "wrong secret", token: token(t, jwt.SigningMethodHS256, "other", "acme", "admin", inAnHour), expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "other algorithm", token: token(t, jwt.SigningMethodHS512, secret, "acme", "admin", inAnHour), expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "unknown role", token: token(t, jwt.SigningMethodHS256, secret, "acme", "owner", inAnHour), expected: auth.Anonymous, err: auth.ErrInvalidCredentials},
+		{name: "key wins over token", apiKey: "partner-key", token: token(t, jwt.SigningMethodHS256, secret, "alice", "admin", inAnHour), expected: auth.Principal{Subject: "acme", Role: auth.RolePartner}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(tc.apiKey, tc.token)
+			assert.Equal(t, err, tc.err)
+			assert.Equal(t, p, tc.expected)
+		})
+	}
+}
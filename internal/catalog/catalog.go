@@ -0,0 +1,289 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/charmbracelet/log"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MinterContract   string = "minter"
+	OffseterContract string = "offseter"
+	YielderContract  string = "yielder"
+	MigratorContract string = "migrator"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+// Live funding of project slot, value in $ with 10^6 precision
+type Funding struct {
+	Value   *u256.Int
+	Funders int64
+}
+
+type Attribute struct {
+	TraitType   string `json:"trait_type"`
+	DisplayType string `json:"display_type"`
+	Value       string `json:"value"`
+}
+
+// Slot uri of a project slot, as last fetched from chain
+type Project struct {
+	UpdatedAt   time.Time
+	Address     string `gorm:"uniqueIndex:idx_projects_slot,priority:1"`
+	Slot        string `gorm:"uniqueIndex:idx_projects_slot,priority:2"`
+	Name        string `gorm:"index"`
+	Description string
+	Image       string
+	ExternalUrl string
+	YoutubeUrl  string
+	Attributes  []Attribute `gorm:"serializer:json;type:jsonb"`
+	// Hash of slot uri, project is only written when it changes
+	Hash string
+	ID   ulid.ULID `gorm:"primaryKey"`
+}
+
+// Protocol contract of configuration along with the project slot it works on.
+// Slot is empty for contracts working on every slot of project (migrators)
+type ProjectContract struct {
+	Address        string `gorm:"unique"`
+	Name           string
+	Kind           string
+	ProjectAddress string `gorm:"index"`
+	Slot           string
+	ID             ulid.ULID `gorm:"primaryKey"`
+}
+
+func SlotFelt(slot uint64) string {
+	return starknet.FeltFromUint64(slot).String()
+}
+
+func ProjectFromSlotUri(address string, slot uint64, uri *starknet.SlotUri) Project {
+	p := Project{
+		Address:     starknet.NormalizeAddress(address).String(),
+		Slot:        SlotFelt(slot),
+		Name:        uri.Name,
+		Description: uri.Description,
+		Image:       uri.Image,
+		ExternalUrl: uri.ExternalUrl,
+		YoutubeUrl:  uri.YoutubeUrl,
+		Attributes:  []Attribute{},
+	}
+	for _, a := range uri.Attributes {
+		var value string
+		if a.Value != nil {
+			value = fmt.Sprint(a.Value)
+		}
+		p.Attributes = append(p.Attributes, Attribute{TraitType: a.TraitType, DisplayType: a.DisplayType, Value: value})
+	}
+	b, _ := json.Marshal(uri)
+	sum := sha256.Sum256(b)
+	p.Hash = hex.EncodeToString(sum[:])
+	return p
+}
+
+// Upsert project slot, nothing is written when slot uri did not change
+func SaveSlotUri(db *gorm.DB, address string, slot uint64, uri *starknet.SlotUri) error {
+	if uri == nil {
+		return nil
+	}
+	p := ProjectFromSlotUri(address, slot, uri)
+	p.UpdatedAt = time.Now()
+	p.ID = ulid.Make()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "address"}, {Name: "slot"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "name", "description", "image", "external_url", "youtube_url", "attributes", "hash"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "projects.hash <> excluded.hash"}}},
+	}).Create(&p).Error
+}
+
+// Fetch slot uri from chain and keep it in catalog
+func FetchSlotUri(db *gorm.DB, rpc starknet.StarknetRpcClient, address string, slot uint64) (*starknet.SlotUri, error) {
+	uri, err := starknet.GetSlotUri(rpc, address, slot)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveSlotUri(db, address, slot, uri); err != nil {
+		log.Error("failed to save project in catalog", "address", address, "slot", slot, "error", err)
+	}
+	return uri, nil
+}
+
+// Kind of protocol contract from the events it emits, empty for projects and unknown contracts
+func ContractKind(c config.Contract) string {
+	for _, name := range c.Events {
+		kind, _, _ := strings.Cut(name, ":")
+		switch kind {
+		case MinterContract, OffseterContract, YielderContract, MigratorContract:
+			return kind
+		}
+	}
+	return ""
+}
+
+// Link configured contracts to the project slot they work on
+func SyncContracts(db *gorm.DB, rpc starknet.StarknetRpcClient, contracts []config.Contract) error {
+	for _, c := range contracts {
+		kind := ContractKind(c)
+		if kind == "" {
+			continue
+		}
+		pc := ProjectContract{Address: starknet.NormalizeAddress(c.Address).String(), Name: c.Name, Kind: kind, ID: ulid.Make()}
+		if kind == MigratorContract {
+			target, err := starknet.MigratorTargetAddress(rpc, c.Address)
+			if err != nil {
+				log.Error("failed to get migrator target", "contract", c.Name, "error", err)
+				continue
+			}
+			pc.ProjectAddress = starknet.NormalizeAddress(target).String()
+		} else {
+			// NOTE: offseters and yielders expose the same getters as minters
+			address, err := starknet.MinterGetProjectAddress(rpc, c.Address)
+			if err != nil {
+				log.Error("failed to get contract project", "contract", c.Name, "error", err)
+				continue
+			}
+			slot, err := starknet.MinterGetProjectSlot(rpc, c.Address)
+			if err != nil {
+				log.Error("failed to get contract project slot", "contract", c.Name, "error", err)
+				continue
+			}
+			pc.ProjectAddress = starknet.NormalizeAddress(address).String()
+			pc.Slot = SlotFelt(slot)
+		}
+
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "address"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "kind", "project_address", "slot"}),
+		}).Create(&pc).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetProjects(db *gorm.DB) ([]Project, error) {
+	var projects []Project
+	err := db.Order("name ASC, slot ASC").Find(&projects).Error
+	return projects, err
+}
+
+func GetProject(db *gorm.DB, address string, slot uint64) (*Project, error) {
+	var p Project
+	err := db.Where("address = ? AND slot = ?", starknet.NormalizeAddress(address).String(), SlotFelt(slot)).First(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Identifies a project slot
+type ProjectKey struct {
+	Address string
+	Slot    string
+}
+
+func (p *Project) Key() ProjectKey {
+	return ProjectKey{Address: p.Address, Slot: p.Slot}
+}
+
+func projectAddresses(projects []Project) []string {
+	var addresses []string
+	for _, p := range projects {
+		addresses = append(addresses, p.Address)
+	}
+	return addresses
+}
+
+// Contracts working on each project slot, ordered by kind
+func GetProjectsContracts(db *gorm.DB, projects []Project) (map[ProjectKey][]ProjectContract, error) {
+	if len(projects) == 0 {
+		return map[ProjectKey][]ProjectContract{}, nil
+	}
+	var contracts []ProjectContract
+	if err := db.Where("project_address IN ?", projectAddresses(projects)).Order("kind ASC, name ASC").Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	return ContractsByProject(projects, contracts), nil
+}
+
+// Contracts of project slot along with contracts working on every slot of project
+func ContractsByProject(projects []Project, contracts []ProjectContract) map[ProjectKey][]ProjectContract {
+	res := make(map[ProjectKey][]ProjectContract)
+	for _, p := range projects {
+		res[p.Key()] = []ProjectContract{}
+		for _, c := range contracts {
+			if c.ProjectAddress == p.Address && (c.Slot == p.Slot || c.Slot == "") {
+				res[p.Key()] = append(res[p.Key()], c)
+			}
+		}
+	}
+	return res
+}
+
+// Bought or airdropped value emitted by a project slot minter
+type FundingRow struct {
+	ProjectAddress string
+	Slot           string
+	Value          string
+	WalletAddress  string
+}
+
+const fundingQuery = `SELECT project_contracts.project_address, project_contracts.slot, domain_events.data->>'value' AS value, domain_events.wallet_address
+	FROM domain_events JOIN project_contracts ON project_contracts.address = domain_events.from_address
+	WHERE domain_events.event_name IN ('minter:buy', 'minter:airdrop') AND project_contracts.kind = ? AND project_contracts.project_address IN ?`
+
+// Bought and airdropped value of project slots, computed from events of their minters as minter value is
+func GetFundings(db *gorm.DB, projects []Project) (map[ProjectKey]*Funding, error) {
+	var rows []FundingRow
+	if len(projects) > 0 {
+		if err := db.Raw(fundingQuery, MinterContract, projectAddresses(projects)).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	return FundingsByProject(projects, rows), nil
+}
+
+// Value is kept in 10^6 units, funders are counted once per project slot
+func FundingsByProject(projects []Project, rows []FundingRow) map[ProjectKey]*Funding {
+	res := make(map[ProjectKey]*Funding)
+	funders := make(map[ProjectKey]map[string]bool)
+	for _, p := range projects {
+		res[p.Key()] = &Funding{Value: u256.NewInt(0)}
+		funders[p.Key()] = make(map[string]bool)
+	}
+	for _, r := range rows {
+		key := ProjectKey{Address: r.ProjectAddress, Slot: r.Slot}
+		f, exists := res[key]
+		if !exists {
+			continue
+		}
+		v, err := u256.FromHex(r.Value)
+		if err != nil {
+			log.Error("failed to parse bought value", "project", r.ProjectAddress, "slot", r.Slot, "value", r.Value, "error", err)
+			continue
+		}
+		f.Value.Add(f.Value, v)
+		funders[key][r.WalletAddress] = true
+	}
+	for key, f := range res {
+		f.Funders = int64(len(funders[key]))
+	}
+	return res
+}
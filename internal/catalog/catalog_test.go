@@ -0,0 +1,92 @@
+package catalog_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/carbonable/leaderboard/internal/catalog"
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"gotest.tools/assert"
+)
+
+const slotUriJson = `{"name":"Banegas Farm","description":"Mangrove restoration","image":"ipfs://banegas","external_url":"https://carbonable.io","youtube_url":"",
+	"attributes":[{"trait_type":"Country","value":"Costa Rica"},{"trait_type":"Ton Equivalent","display_type":"number","value":"1573"}]}`
+
+func slotUri(t *testing.T, s string) *starknet.SlotUri {
+	var uri starknet.SlotUri
+	assert.NilError(t, json.Unmarshal([]byte(s), &uri))
+	return &uri
+}
+
+func TestProjectFromSlotUri(t *testing.T) {
+	uri := slotUri(t, slotUriJson)
+	p := catalog.ProjectFromSlotUri("0x00130b5a3035eef0470cff2f9a450a7a6856a3c5a4ea3f5b7886c2d03a50d2bf", 1, uri)
+
+	assert.Equal(t, p.Address, "0x130b5a3035eef0470cff2f9a450a7a6856a3c5a4ea3f5b7886c2d03a50d2bf")
+	assert.Equal(t, p.Slot, "0x1")
+	assert.Equal(t, p.Name, "Banegas Farm")
+	assert.Equal(t, len(p.Attributes), 2)
+	assert.Equal(t, p.Attributes[0].Value, "Costa Rica")
+	assert.Equal(t, p.Attributes[1].DisplayType, "number")
+	assert.Equal(t, p.Attributes[1].Value, "1573")
+
+	same := catalog.ProjectFromSlotUri("0x130b5a3035eef0470cff2f9a450a7a6856a3c5a4ea3f5b7886c2d03a50d2bf", 1, slotUri(t, slotUriJson))
+	assert.Equal(t, p.Hash, same.Hash)
+
+	uri.Description = "Mangrove and forest restoration"
+	changed := catalog.ProjectFromSlotUri("0x130b5a3035eef0470cff2f9a450a7a6856a3c5a4ea3f5b7886c2d03a50d2bf", 1, uri)
+	assert.Assert(t, p.Hash != changed.Hash)
+}
+
+func TestContractKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		events   map[string]string
+		expected string
+	}{
+		{name: "minter", events: map[string]string{"Buy": "minter:buy", "Airdrop": "minter:airdrop"}, expected: catalog.MinterContract},
+		{name: "offseter", events: map[string]string{"Claim": "offseter:claim"}, expected: catalog.OffseterContract},
+		{name: "yielder", events: map[string]string{"Deposit": "yielder:deposit"}, expected: catalog.YielderContract},
+		{name: "migrator", events: map[string]string{"Migration": "migrator:migration"}, expected: catalog.MigratorContract},
+		{name: "project", events: map[string]string{"Transfer": "project:transfer"}, expected: ""},
+		{name: "referral", events: map[string]string{"Referral": "referral:registered"}, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, catalog.ContractKind(config.Contract{Events: tc.events}), tc.expected)
+		})
+	}
+}
+
+func TestContractsByProject(t *testing.T) {
+	projects := []catalog.Project{{Address: "0x1", Slot: "0x1"}, {Address: "0x1", Slot: "0x2"}}
+	contracts := []catalog.ProjectContract{
+		{Address: "0xa", Kind: catalog.MigratorContract, ProjectAddress: "0x1"},
+		{Address: "0xb", Kind: catalog.MinterContract, ProjectAddress: "0x1", Slot: "0x1"},
+		{Address: "0xc", Kind: catalog.MinterContract, ProjectAddress: "0x1", Slot: "0x2"},
+		{Address: "0xd", Kind: catalog.MinterContract, ProjectAddress: "0x2", Slot: "0x1"},
+	}
+
+	res := catalog.ContractsByProject(projects, contracts)
+	assert.DeepEqual(t, res[projects[0].Key()], []catalog.ProjectContract{contracts[0], contracts[1]})
+	assert.DeepEqual(t, res[projects[1].Key()], []catalog.ProjectContract{contracts[0], contracts[2]})
+}
+
+func TestFundingsByProject(t *testing.T) {
+	projects := []catalog.Project{{Address: "0x1", Slot: "0x1"}, {Address: "0x1", Slot: "0x2"}}
+	rows := []catalog.FundingRow{
+		// 100.5$ and 20$ by the same wallet
+		{ProjectAddress: "0x1", Slot: "0x1", Value: "0x5fd8220", WalletAddress: "0xa"},
+		{ProjectAddress: "0x1", Slot: "0x1", Value: "0x1312d00", WalletAddress: "0xa"},
+		{ProjectAddress: "0x1", Slot: "0x1", Value: "not hex", WalletAddress: "0xb"},
+		{ProjectAddress: "0x2", Slot: "0x1", Value: "0x1312d00", WalletAddress: "0xc"},
+	}
+
+	res := catalog.FundingsByProject(projects, rows)
+	assert.Equal(t, res[projects[0].Key()].Value.Dec(), "120500000")
+	assert.Equal(t, res[projects[0].Key()].Funders, int64(1))
+	assert.Equal(t, res[projects[1].Key()].Value.Dec(), "0")
+	assert.Equal(t, res[projects[1].Key()].Funders, int64(0))
+}
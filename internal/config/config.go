@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,9 +20,25 @@ type Contract struct {
 	Name    string            `yaml:"name"`
 }
 
+// Time-boxed leaderboard computed alongside the all-time one
+type Season struct {
+	StartAt time.Time `yaml:"start_at"`
+	EndAt   time.Time `yaml:"end_at"`
+	Name    string    `yaml:"name"`
+	Rules   string    `yaml:"rules"`
+}
+
+// Wallet scored but hidden from ranking (team wallets, airdrop distributors...)
+type Exclusion struct {
+	Address string `yaml:"address"`
+	Reason  string `yaml:"reason"`
+}
+
 type Config struct {
-	Contracts  []Contract `yaml:"contracts"`
-	StartBlock uint64     `yaml:"start_block"`
+	Contracts  []Contract  `yaml:"contracts"`
+	Seasons    []Season    `yaml:"seasons"`
+	Exclusions []Exclusion `yaml:"exclusions"`
+	StartBlock uint64      `yaml:"start_block"`
 }
 
 func (c *Config) GetContract(address string) *Contract {
@@ -32,6 +50,24 @@ func (c *Config) GetContract(address string) *Contract {
 	return nil
 }
 
+// Addresses of contracts that are not projects (minters, yielders, offseters...)
+func (c *Config) ProtocolAddresses() []string {
+	var addresses []string
+	for _, contract := range c.Contracts {
+		isProject := false
+		for _, name := range contract.Events {
+			if strings.HasPrefix(name, "project:") {
+				isProject = true
+				break
+			}
+		}
+		if !isProject {
+			addresses = append(addresses, contract.Address)
+		}
+	}
+	return addresses
+}
+
 func FromYamlFile(file string) (*Config, error) {
 	cfg := Config{}
 
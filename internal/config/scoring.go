@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Boost tier, coef is a percentage (150 = x1.5)
+type Tier struct {
+	Step uint64 `yaml:"step"`
+	Coef uint64 `yaml:"coef"`
+}
+
+type Builder struct {
+	Projects map[string]uint64 `yaml:"projects"`
+	Rule     string            `yaml:"rule"`
+	// Rule default is used when omitted, an explicit 0 is kept
+	Points     *uint64 `yaml:"points"`
+	Multiplier *uint64 `yaml:"multiplier"`
+	// Rates indexed by contract type
+	Rates map[string]uint64 `yaml:"rates"`
+	// Addresses ignored by the rule, e.g. transfer destinations that are not clawed back
+	Exclude []string `yaml:"exclude"`
+}
+
+type Booster struct {
+	Boost   string `yaml:"boost"`
+	Project string `yaml:"project"`
+	Slot    string `yaml:"slot"`
+	Tiers   []Tier `yaml:"tiers"`
+}
+
+type RuleSet struct {
+	// multiplicative (default), additive or max
+	Stacking string    `yaml:"stacking"`
+	Builders []Builder `yaml:"builders"`
+	Boosters []Booster `yaml:"boosters"`
+	// Maximum overall boost coef in percent, 0 means no cap
+	Cap uint64 `yaml:"cap"`
+}
+
+// Scoring rules used by the aggregator to compute leaderboard lines
+type Scoring struct {
+	RuleSets map[string]RuleSet `yaml:"rule_sets"`
+	Version  string             `yaml:"version"`
+}
+
+// Unknown keys are refused, a misspelled setting would silently fall back to rule default
+func ScoringFromYamlFile(file string) (*Scoring, error) {
+	cfg := Scoring{}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParseConfig, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnmarshalFailed, file, err)
+	}
+
+	return &cfg, nil
+}
@@ -130,6 +130,39 @@ func (i *EventIndexer) fetchBlock(blockNumber uint64) (*starknet.GetBlockRespons
 	return nil, errors.New("block not found")
 }
 
+// Ids of events emitted by contract as indexEvent names them, indexed by block number.
+// Only blocks of contract index are read, address is padded the way index keys are
+func ContractEventIds(storage Storage, address string) (map[uint64][]string, error) {
+	key := []byte("IDX#" + address)
+	if !storage.Has(key) {
+		return nil, nil
+	}
+	idx := NewContractIndex(0)
+	if err := idx.Decode(storage.Get(key)); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[uint64][]string)
+	for _, number := range idx.Blocks {
+		encoded := storage.Get([]byte(fmt.Sprintf("BLOCK#%d", number)))
+		if encoded == nil {
+			continue
+		}
+		var block starknet.GetBlockResponse
+		if err := gob.NewDecoder(bytes.NewBuffer(encoded)).Decode(&block); err != nil {
+			return nil, err
+		}
+		for _, tx := range block.TransactionReceipts {
+			for eventIdx, event := range tx.Events {
+				if starknet.EnsureStarkFelt(event.FromAddress) == address {
+					ids[number] = append(ids[number], fmt.Sprintf("%s_%d", tx.TransactionHash, eventIdx))
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
 func (i *EventIndexer) indexTransaction(address string, block *starknet.GetBlockResponse) {
 	for _, tx := range block.Transactions {
 		if starknet.EnsureStarkFelt(tx.SenderAddress) != address {
@@ -165,6 +198,7 @@ func (i *EventIndexer) indexEvent(address string, block *starknet.GetBlockRespon
 			eventId := fmt.Sprintf("%s_%d", tx.TransactionHash, eventIdx)
 			event.EventId = eventId
 			event.RecordedAt = time.Unix(int64(block.Timestamp), 0)
+			event.BlockNumber = block.BlockNumber
 
 			err := encoder.Encode(event)
 			if err != nil {
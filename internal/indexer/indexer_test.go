@@ -0,0 +1,55 @@
+package indexer_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"gotest.tools/assert"
+)
+
+type mapStorage map[string][]byte
+
+func (s mapStorage) Get(id []byte) []byte { return s[string(id)] }
+
+func (s mapStorage) Has(id []byte) bool {
+	_, exists := s[string(id)]
+	return exists
+}
+
+func (s mapStorage) Set(key []byte, value []byte) error {
+	s[string(key)] = value
+	return nil
+}
+
+func (s mapStorage) Scan(prefix []byte) [][]byte { return nil }
+
+func TestContractEventIds(t *testing.T) {
+	contract := starknet.EnsureStarkFelt("0x2")
+	storage := mapStorage{}
+
+	var buf bytes.Buffer
+	assert.NilError(t, gob.NewEncoder(&buf).Encode(starknet.GetBlockResponse{BlockNumber: 42, TransactionReceipts: []starknet.TransactionReceipt{
+		{TransactionHash: "0xa", Events: []starknet.Event{{FromAddress: "0x3"}, {FromAddress: "0x2"}}},
+		{TransactionHash: "0xb", Events: []starknet.Event{{FromAddress: "0x2"}}},
+	}}))
+	assert.NilError(t, storage.Set([]byte("BLOCK#42"), buf.Bytes()))
+
+	idx := indexer.NewContractIndex(40)
+	idx.AddBlock(42)
+	// block indexed but not stored is skipped
+	idx.AddBlock(43)
+	encoded, err := idx.Encode()
+	assert.NilError(t, err)
+	assert.NilError(t, storage.Set([]byte("IDX#"+contract), encoded.Bytes()))
+
+	ids, err := indexer.ContractEventIds(storage, contract)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ids, map[uint64][]string{42: {"0xa_1", "0xb_0"}})
+
+	ids, err = indexer.ContractEventIds(storage, starknet.EnsureStarkFelt("0x3"))
+	assert.NilError(t, err)
+	assert.Assert(t, ids == nil)
+}
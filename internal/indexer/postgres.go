@@ -50,6 +50,17 @@ func (s *PgStorage) Scan(prefix []byte) [][]byte {
 	return results
 }
 
+// Entries with prefix in key order, starting after given key
+func (s *PgStorage) ScanPage(prefix []byte, after []byte, limit int) ([]KVStore, error) {
+	var results []KVStore
+	q := s.db.Model(&KVStore{}).Where("id LIKE ?", string(prefix)+"%")
+	if len(after) > 0 {
+		q = q.Where("id > ?", string(after))
+	}
+	err := q.Order("id ASC").Limit(limit).Find(&results).Error
+	return results, err
+}
+
 func NewPgStorage(db *gorm.DB) *PgStorage {
 	return &PgStorage{
 		db,
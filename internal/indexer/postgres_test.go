@@ -0,0 +1,56 @@
+package indexer_test
+
+import (
+	"testing"
+
+	"github.com/carbonable/leaderboard/internal/indexer"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gotest.tools/assert"
+)
+
+// Statement ScanPage would run against postgres
+func scanPageStatement(t *testing.T, prefix string, after string, limit int) (string, []any) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.NilError(t, err)
+
+	var sql string
+	var vars []any
+	err = db.Callback().Query().After("gorm:query").Register("capture", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+		vars = tx.Statement.Vars
+	})
+	assert.NilError(t, err)
+
+	_, err = indexer.NewPgStorage(db).ScanPage([]byte(prefix), []byte(after), limit)
+	assert.NilError(t, err)
+	return sql, vars
+}
+
+func TestScanPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		after string
+		sql   string
+		vars  []any
+	}{
+		{
+			name: "first page",
+			sql:  `SELECT * FROM "kv_stores" WHERE id LIKE $1 ORDER BY id ASC LIMIT 11`,
+			vars: []any{"0x2#TX#%"},
+		},
+		{
+			name:  "next page",
+			after: "0x2#TX#0xa",
+			sql:   `SELECT * FROM "kv_stores" WHERE id LIKE $1 AND id > $2 ORDER BY id ASC LIMIT 11`,
+			vars:  []any{"0x2#TX#%", "0x2#TX#0xa"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := scanPageStatement(t, "0x2#TX#", tt.after, 11)
+			assert.Equal(t, sql, tt.sql)
+			assert.DeepEqual(t, vars, tt.vars)
+		})
+	}
+}
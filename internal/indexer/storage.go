@@ -20,6 +20,10 @@ type (
 		Set(key []byte, value []byte) error
 		Scan(prefix []byte) [][]byte
 	}
+	// Storage able to page through a prefix instead of loading every value
+	PageScanner interface {
+		ScanPage(prefix []byte, after []byte, limit int) ([]KVStore, error)
+	}
 )
 
 type (
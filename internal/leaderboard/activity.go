@@ -0,0 +1,230 @@
+package leaderboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidActivityCursor = errors.New("invalid activity cursor")
+
+// Domain event decoded for display, along with points it earned in the current ranking
+type Activity struct {
+	RecordedAt time.Time
+	EventId    string
+	// Empty for events that do not come from chain
+	TxHash    string
+	EventName string
+	Source    string
+	Project   string
+	Slot      string
+	// Value or claimed amount in $, converted from 10^6 units
+	Amount  string
+	TokenId string
+	From    string
+	To      string
+	Points  []Point
+	ID      ulid.ULID
+}
+
+type ActivityFilter struct {
+	EventNames []string
+	Project    string
+	From       *time.Time
+	Until      *time.Time
+}
+
+// Keyset cursor, activity is ordered from newest to oldest event
+type ActivityCursor struct {
+	RecordedAt time.Time
+	ID         ulid.ULID
+}
+
+func (c ActivityCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d|%s", c.RecordedAt.UnixNano(), c.ID)))
+}
+
+func DecodeActivityCursor(s string) (*ActivityCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+	parts := strings.Split(string(b), "|")
+	if len(parts) != 2 {
+		return nil, ErrInvalidActivityCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+	parsed, err := ulid.Parse(parts[1])
+	if err != nil {
+		return nil, ErrInvalidActivityCursor
+	}
+	return &ActivityCursor{RecordedAt: time.Unix(0, nanos), ID: parsed}, nil
+}
+
+// Block bounds are included
+type ContractEventFilter struct {
+	EventNames []string
+	FromBlock  *uint64
+	ToBlock    *uint64
+}
+
+type ActivityPage struct {
+	Activities  []Activity
+	HasNextPage bool
+}
+
+// Transaction hash is the event id without the index of event in transaction
+func TxHashFromEventId(eventId string) string {
+	i := strings.LastIndex(eventId, "_")
+	if i <= 0 || !strings.HasPrefix(eventId, "0x") {
+		return ""
+	}
+	return eventId[:i]
+}
+
+// Converts an hex amount in 10^6 units to a decimal string
+func FormatAmount(hex string) string {
+	if hex == "" {
+		return ""
+	}
+	v, err := u256.FromHex(hex)
+	if err != nil {
+		return ""
+	}
+	var whole, fraction u256.Int
+	whole.Div(v, u256.NewInt(1000000))
+	fraction.Mod(v, u256.NewInt(1000000))
+	if fraction.IsZero() {
+		return whole.Dec()
+	}
+	return strings.TrimRight(fmt.Sprintf("%s.%06d", whole.Dec(), fraction.Uint64()), "0")
+}
+
+func ActivityFromEvent(e DomainEvent, points []Point) Activity {
+	amount := e.Data["value"]
+	if amount == "" {
+		amount = e.Data["amount"]
+	}
+	tokenId := e.Data["token_id"]
+	if tokenId == "" {
+		tokenId = e.Data["to_token_id"]
+	}
+	from := e.Data["from"]
+	if from == "" {
+		from = e.Data["address"]
+	}
+	return Activity{
+		RecordedAt: e.RecordedAt,
+		EventId:    e.EventId,
+		TxHash:     TxHashFromEventId(e.EventId),
+		EventName:  e.EventName,
+		Source:     e.Source,
+		Project:    e.Metadata["project_name"],
+		Slot:       e.Metadata["slot"],
+		Amount:     FormatAmount(amount),
+		TokenId:    tokenId,
+		From:       from,
+		To:         e.Data["to"],
+		Points:     points,
+		ID:         e.ID,
+	}
+}
+
+// Points of line indexed by the event that earned them
+func pointsByEvent(points Points) map[string][]Point {
+	res := make(map[string][]Point)
+	for _, p := range points {
+		if id := p.Metadata["event_id"]; id != "" {
+			res[id] = append(res[id], p)
+		}
+	}
+	return res
+}
+
+// Events stored for wallet, newest first. Points come from the all-time line of wallet identity
+func GetWalletActivity(db *gorm.DB, wallet string, filter ActivityFilter, first int, after *ActivityCursor) (*ActivityPage, error) {
+	if first < 1 || first > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	q := db.Model(&DomainEvent{}).Where("wallet_address = ?", wallet)
+	if len(filter.EventNames) > 0 {
+		q = q.Where("event_name IN ?", filter.EventNames)
+	}
+	if filter.Project != "" {
+		q = q.Where("metadata->>'project_name' = ?", filter.Project)
+	}
+	if filter.From != nil {
+		q = q.Where("recorded_at >= ?", *filter.From)
+	}
+	if filter.Until != nil {
+		q = q.Where("recorded_at < ?", *filter.Until)
+	}
+	events, hasNext, err := eventsPage(q, first, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &ActivityPage{Activities: []Activity{}, HasNextPage: hasNext}
+	if len(events) == 0 {
+		return page, nil
+	}
+
+	identity, err := IdentityOf(db, wallet)
+	if err != nil {
+		return nil, err
+	}
+	var line LeaderboardLine
+	err = db.Where("wallet_address = ?", identity).First(&line).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	points := pointsByEvent(line.Points)
+	for _, e := range events {
+		page.Activities = append(page.Activities, ActivityFromEvent(e, points[e.EventId]))
+	}
+	return page, nil
+}
+
+// Keyset page of events, newest first
+func eventsPage(q *gorm.DB, first int, after *ActivityCursor) ([]DomainEvent, bool, error) {
+	if after != nil {
+		q = q.Where("(recorded_at < ? OR (recorded_at = ? AND id < ?))", after.RecordedAt, after.RecordedAt, after.ID)
+	}
+	var events []DomainEvent
+	if err := q.Order("recorded_at DESC, id DESC").Limit(first + 1).Find(&events).Error; err != nil {
+		return nil, false, err
+	}
+	if len(events) > first {
+		return events[:first], true, nil
+	}
+	return events, false, nil
+}
+
+// Events emitted by contract, newest first
+func GetContractEvents(db *gorm.DB, contract string, filter ContractEventFilter, first int, after *ActivityCursor) ([]DomainEvent, bool, error) {
+	if first < 1 || first > MaxPageSize {
+		return nil, false, ErrInvalidPageSize
+	}
+	q := db.Model(&DomainEvent{}).Where("from_address = ? AND source = ?", starknet.NormalizeAddress(contract).String(), EventSourceChain)
+	if len(filter.EventNames) > 0 {
+		q = q.Where("event_name IN ?", filter.EventNames)
+	}
+	if filter.FromBlock != nil {
+		q = q.Where("block_number >= ?", *filter.FromBlock)
+	}
+	if filter.ToBlock != nil {
+		q = q.Where("block_number <= ?", *filter.ToBlock)
+	}
+	return eventsPage(q, first, after)
+}
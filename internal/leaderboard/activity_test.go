@@ -0,0 +1,67 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/oklog/ulid/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+var _ = Describe("Wallet activity", func() {
+	It("should get transaction hash from event id", func() {
+		Expect(leaderboard.TxHashFromEventId("0x4aa5ea227fb0457e4cbe20be80a1896796c2d07c9032835dbbd395629c8f42f_3")).To(Equal("0x4aa5ea227fb0457e4cbe20be80a1896796c2d07c9032835dbbd395629c8f42f"))
+		Expect(leaderboard.TxHashFromEventId("grant#01HQ0000000000000000000000")).To(Equal(""))
+		Expect(leaderboard.TxHashFromEventId("")).To(Equal(""))
+	})
+
+	It("should convert amounts from 10^6 units", func() {
+		Expect(leaderboard.FormatAmount("0x5f5e100")).To(Equal("100"))
+		Expect(leaderboard.FormatAmount("0x5f6f270")).To(Equal("100.07"))
+		Expect(leaderboard.FormatAmount("0x1")).To(Equal("0.000001"))
+		Expect(leaderboard.FormatAmount("")).To(Equal(""))
+	})
+
+	It("should decode event with the points it earned", func() {
+		buy := buyProjectEvt("Karathuru", 100*1000000)
+		line := leaderboard.NewPersonnalRanking(holder, []leaderboard.DomainEvent{buy}).ComputeScore(leaderboard.MintPageCalculatorManager())
+		Expect(line.Points[0].Metadata["event_id"]).To(Equal(buy.EventId))
+
+		activity := leaderboard.ActivityFromEvent(buy, line.Points)
+		Expect(activity.TxHash).To(Equal("0x4aa5ea227fb0457e4cbe20be80a1896796c2d07c9032835dbbd395629c8f42f"))
+		Expect(activity.Project).To(Equal("Karathuru"))
+		Expect(activity.Amount).To(Equal("100"))
+		Expect(activity.From).To(Equal(buy.Data["address"]))
+		Expect(activity.Points).To(HaveLen(1))
+	})
+
+	It("should round trip cursor", func() {
+		c := leaderboard.ActivityCursor{RecordedAt: time.Unix(t0, 42), ID: ulid.Make()}
+		decoded, err := leaderboard.DecodeActivityCursor(c.Encode())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded.RecordedAt.Equal(c.RecordedAt)).To(BeTrue())
+		Expect(decoded.ID).To(Equal(c.ID))
+
+		_, err = leaderboard.DecodeActivityCursor("MTIzfG5vdGF1bGlk")
+		Expect(err).To(MatchError(leaderboard.ErrInvalidActivityCursor))
+	})
+
+	It("should filter contract events on included block bounds", func() {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+		Expect(err).NotTo(HaveOccurred())
+		var sql string
+		Expect(db.Callback().Query().After("gorm:query").Register("capture", func(tx *gorm.DB) {
+			sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		})).To(Succeed())
+
+		from, to := uint64(41), uint64(42)
+		_, _, err = leaderboard.GetContractEvents(db, "0x2", leaderboard.ContractEventFilter{FromBlock: &from, ToBlock: &to}, 10, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sql).To(ContainSubstring("block_number >= 41"))
+		Expect(sql).To(ContainSubstring("block_number <= 42"))
+		Expect(sql).NotTo(ContainSubstring("recorded_at >="))
+	})
+})
@@ -0,0 +1,228 @@
+package leaderboard
+
+import (
+	"fmt"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/charmbracelet/log"
+	u256 "github.com/holiman/uint256"
+	"gorm.io/gorm"
+)
+
+// Rewrite every stored wallet address in canonical form.
+// Lines of a wallet stored under several forms are merged into one.
+func NormalizeStoredAddresses(db *gorm.DB) error {
+	if err := normalizeDomainEvents(db); err != nil {
+		return fmt.Errorf("domain events: %w", err)
+	}
+	if err := normalizeLeaderboardLines(db); err != nil {
+		return fmt.Errorf("leaderboard lines: %w", err)
+	}
+	if err := normalizeSeasonLeaderboardLines(db); err != nil {
+		return fmt.Errorf("season leaderboard lines: %w", err)
+	}
+
+	columns := []struct {
+		table  string
+		column string
+		unique bool
+	}{
+		{"referral_codes", "wallet_address", false},
+		{"referrals", "referee_wallet", true},
+		{"referrals", "referrer_wallet", false},
+		{"wallet_exclusions", "wallet_address", true},
+		{"wallet_links", "wallet_address", true},
+		{"wallet_links", "identity_wallet", false},
+	}
+	for _, c := range columns {
+		if err := normalizeColumn(db, c.table, c.column, c.unique); err != nil {
+			return fmt.Errorf("%s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func normalizeDomainEvents(db *gorm.DB) error {
+	var events []DomainEvent
+	updated := 0
+	res := db.FindInBatches(&events, 500, func(tx *gorm.DB, batch int) error {
+		for _, e := range events {
+			wallet := starknet.NormalizeAddress(e.WalletAddress).String()
+			from := starknet.NormalizeAddress(e.FromAddress).String()
+			changed := wallet != e.WalletAddress || from != e.FromAddress
+			for _, k := range AddressDataKeys {
+				if v, exists := e.Data[k]; exists && starknet.NormalizeAddress(v).String() != v {
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+
+			e.WalletAddress = wallet
+			e.FromAddress = from
+			e.Data = normalizeEventAddresses(e.Data)
+			if err := tx.Model(&e).Select("wallet_address", "from_address", "data").Updates(&e).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	log.Info("normalized domain event addresses", "updated", updated)
+	return res.Error
+}
+
+func normalizeLeaderboardLines(db *gorm.DB) error {
+	var lines []LeaderboardLine
+	if err := db.Find(&lines).Error; err != nil {
+		return err
+	}
+
+	groups := make(map[string][]LeaderboardLine)
+	for _, l := range lines {
+		w := starknet.NormalizeAddress(l.WalletAddress).String()
+		groups[w] = append(groups[w], l)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for w, group := range groups {
+			if len(group) == 1 && group[0].WalletAddress == w {
+				continue
+			}
+			merged := MergeLines(w, group)
+			for _, l := range group {
+				if err := tx.Delete(&LeaderboardLine{}, "id = ?", l.ID).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&merged).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func normalizeSeasonLeaderboardLines(db *gorm.DB) error {
+	var lines []SeasonLeaderboardLine
+	if err := db.Find(&lines).Error; err != nil {
+		return err
+	}
+
+	groups := make(map[string][]SeasonLeaderboardLine)
+	for _, l := range lines {
+		key := l.SeasonID.String() + "#" + starknet.NormalizeAddress(l.WalletAddress).String()
+		groups[key] = append(groups[key], l)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, group := range groups {
+			w := starknet.NormalizeAddress(group[0].WalletAddress).String()
+			if len(group) == 1 && group[0].WalletAddress == w {
+				continue
+			}
+			var toMerge []LeaderboardLine
+			for _, l := range group {
+				toMerge = append(toMerge, l.LeaderboardLine)
+				if err := tx.Delete(&SeasonLeaderboardLine{}, "id = ?", l.ID).Error; err != nil {
+					return err
+				}
+			}
+			line := MergeLines(w, toMerge)
+			merged := SeasonLeaderboardLineFromLine(group[0].SeasonID, &line)
+			if err := tx.Create(&merged).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Lines computed for the same wallet under different address forms, their events never overlap so scores add up
+func MergeLines(wallet string, lines []LeaderboardLine) LeaderboardLine {
+	merged := LeaderboardLine{
+		WalletAddress: wallet,
+		RulesVersion:  lines[0].RulesVersion,
+		ID:            lines[0].ID,
+	}
+	total := u256.NewInt(0)
+	fund, farming, other := u256.NewInt(0), u256.NewInt(0), u256.NewInt(0)
+	wallets := make(map[string]*u256.Int)
+	var order []string
+	for _, l := range lines {
+		merged.Points = append(merged.Points, l.Points...)
+		if l.FirstActivityAt != nil && (merged.FirstActivityAt == nil || l.FirstActivityAt.Before(*merged.FirstActivityAt)) {
+			merged.FirstActivityAt = l.FirstActivityAt
+		}
+		addDecimal(total, l.TotalScore)
+		addDecimal(fund, l.Categories.Fund)
+		addDecimal(farming, l.Categories.Farming)
+		addDecimal(other, l.Categories.Other)
+		for _, ws := range l.Wallets {
+			w := starknet.NormalizeAddress(ws.WalletAddress).String()
+			if _, exists := wallets[w]; !exists {
+				wallets[w] = u256.NewInt(0)
+				order = append(order, w)
+			}
+			addDecimal(wallets[w], ws.TotalScore)
+		}
+	}
+	for i := range merged.Points {
+		if w, exists := merged.Points[i].Metadata["wallet"]; exists && w != "" {
+			merged.Points[i].Metadata["wallet"] = starknet.NormalizeAddress(w).String()
+		}
+	}
+
+	merged.TotalScore = total.String()
+	merged.Score = scoreValue(total)
+	merged.Categories = CategorisedScore{Fund: fund.String(), Farming: farming.String(), Other: other.String()}
+	for _, w := range order {
+		merged.Wallets = append(merged.Wallets, WalletScore{WalletAddress: w, TotalScore: wallets[w].String()})
+	}
+	return merged
+}
+
+func addDecimal(acc *u256.Int, s string) {
+	if s == "" {
+		return
+	}
+	v, err := u256.FromDecimal(s)
+	if err != nil {
+		log.Error("failed to parse score", "value", s, "error", err)
+		return
+	}
+	acc.Add(acc, v)
+}
+
+// Update column values to canonical form. When column is unique and canonical value is already stored, duplicate row is dropped
+func normalizeColumn(db *gorm.DB, table string, column string, unique bool) error {
+	if !db.Migrator().HasTable(table) {
+		return nil
+	}
+	var values []string
+	if err := db.Table(table).Distinct(column).Pluck(column, &values).Error; err != nil {
+		return err
+	}
+	for _, v := range values {
+		c := starknet.NormalizeAddress(v).String()
+		if c == v {
+			continue
+		}
+		if unique {
+			var count int64
+			if err := db.Table(table).Where(column+" = ?", c).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				log.Warn("dropping duplicate row", "table", table, column, v)
+				if err := db.Exec("DELETE FROM "+table+" WHERE "+column+" = ?", v).Error; err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := db.Exec("UPDATE "+table+" SET "+column+" = ? WHERE "+column+" = ?", c, v).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package leaderboard_test
+
+import (
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MergeLines", func() {
+	It("should merge lines of the same wallet stored under different forms", func() {
+		padded := "0x01e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2"
+		lines := []leaderboard.LeaderboardLine{
+			{
+				WalletAddress: holder,
+				TotalScore:    "1000",
+				Categories:    leaderboard.CategorisedScore{Fund: "1000", Farming: "0", Other: "0"},
+				Points:        leaderboard.Points{{Rule: "amount_funded", Value: 1000000000, Metadata: leaderboard.EventMetadata{"wallet": holder}}},
+				Wallets:       leaderboard.WalletScores{{WalletAddress: holder, TotalScore: "1000"}},
+			},
+			{
+				WalletAddress: padded,
+				TotalScore:    "250",
+				Categories:    leaderboard.CategorisedScore{Fund: "200", Farming: "50", Other: "0"},
+				Points:        leaderboard.Points{{Rule: "amount_funded", Value: 200000000, Metadata: leaderboard.EventMetadata{"wallet": padded}}},
+				Wallets:       leaderboard.WalletScores{{WalletAddress: padded, TotalScore: "250"}},
+			},
+		}
+
+		merged := leaderboard.MergeLines(holder, lines)
+		Expect(merged.WalletAddress).To(Equal(holder))
+		Expect(merged.TotalScore).To(Equal("1250"))
+		Expect(merged.Score).To(Equal(int64(1250)))
+		Expect(merged.Categories).To(Equal(leaderboard.CategorisedScore{Fund: "1200", Farming: "50", Other: "0"}))
+		Expect(merged.Points).To(HaveLen(2))
+		Expect(merged.Points[1].Metadata["wallet"]).To(Equal(holder))
+		Expect(merged.Wallets).To(Equal(leaderboard.WalletScores{{WalletAddress: holder, TotalScore: "1250"}}))
+	})
+})
+
+var _ = Describe("DomainEventFromStarknetEvent", func() {
+	It("should store addresses in canonical form", func() {
+		event := &starknet.Event{
+			FromAddress: "0x00130B5A3035EEF0470CFF2F9A450A7A6856A3C5A4EA3F5B7886C2D03A50D2BF",
+			Keys:        []string{"0x99cd8bde557814842a3121e8ddfd433a539b8c9f14bf31ebf108d12e6196e9", "0x0", "0x01e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2", "0x1"},
+		}
+		data := map[string]string{"from": "0x0", "to": event.Keys[2], "token_id": "0x01"}
+
+		evt := leaderboard.DomainEventFromStarknetEvent(event, "project:transfer", data["to"], data, map[string]string{})
+		Expect(evt.WalletAddress).To(Equal(holder))
+		Expect(evt.FromAddress).To(Equal("0x130b5a3035eef0470cff2f9a450a7a6856a3c5a4ea3f5b7886c2d03a50d2bf"))
+		Expect(evt.Data["to"]).To(Equal(holder))
+		// NOTE: only address keys are normalized
+		Expect(evt.Data["token_id"]).To(Equal("0x01"))
+	})
+})
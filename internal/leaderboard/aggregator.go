@@ -3,11 +3,14 @@ package leaderboard
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/carbonable/leaderboard/internal/config"
 	"github.com/carbonable/leaderboard/internal/starknet"
 	"github.com/charmbracelet/log"
 	"github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
 	"gorm.io/gorm"
 )
 
@@ -17,8 +20,43 @@ type LeaderboardAggregator interface {
 	GetParticipantEvents(wallet string) ([]DomainEvent, error)
 }
 
-type PgLeaderboardAggregator struct {
-	db *gorm.DB
+type (
+	PgLeaderboardAggregatorFunc func(*PgLeaderboardAggregatorOpts)
+	PgLeaderboardAggregatorOpts struct {
+		scoring           *config.Scoring
+		seasons           []Season
+		protocolAddresses []string
+		exclusions        []WalletExclusion
+	}
+
+	PgLeaderboardAggregator struct {
+		db                *gorm.DB
+		scoring           *config.Scoring
+		seasons           []Season
+		protocolAddresses []string
+		exclusions        []WalletExclusion
+		// events protocol stats were last computed from, held by the run saving them
+		stats          StatsWatermark
+		statsComputing sync.Mutex
+	}
+)
+
+// Wait for late indexed events before freezing a closed season
+const seasonFreezeDelay = 1 * time.Hour
+
+type seasonRun struct {
+	scm    *ScoreCalculatorManager
+	lines  []SeasonLeaderboardLine
+	scores []LineScore
+	season Season
+	mu     sync.Mutex
+}
+
+func (sr *seasonRun) add(line SeasonLeaderboardLine, scores []LineScore) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	sr.lines = append(sr.lines, line)
+	sr.scores = append(sr.scores, scores...)
 }
 
 type PgMinterBuyValueAggregator struct {
@@ -26,15 +64,15 @@ type PgMinterBuyValueAggregator struct {
 }
 
 const minterBuyValueAtQuery = `SELECT de.data->>'value' from domain_events de
-where de.event_name IN ('minter:buy', 'minter:airdrop') and de.metadata->>'project_name' = ? and de.recorded_at <= ?;
+where de.event_name IN ('minter:buy', 'minter:airdrop') and de.metadata->>'project_name' = ? and (? = '' or de.metadata->>'slot' = ?) and de.recorded_at <= ?;
 `
 
 // DomainEvents are immutable but replayable. Therefore we need to recompute mintervalue each time.
 // To get minter value properly, get the sum of bought value of the past events
-func (a *PgMinterBuyValueAggregator) GetMinterCurrentValue(identifier string, recordedAt time.Time) (uint256.Int, error) {
+func (a *PgMinterBuyValueAggregator) GetMinterCurrentValue(project string, slot string, recordedAt time.Time) (uint256.Int, error) {
 	var lines []string
 
-	res := a.db.Raw(minterBuyValueAtQuery, identifier, recordedAt).Scan(&lines)
+	res := a.db.Raw(minterBuyValueAtQuery, project, slot, slot, recordedAt).Scan(&lines)
 	if res.Error != nil {
 		return uint256.Int{}, res.Error
 	}
@@ -45,7 +83,7 @@ func (a *PgMinterBuyValueAggregator) GetMinterCurrentValue(identifier string, re
 	}
 	sum.Div(sum, uint256.NewInt(1e6))
 
-	log.Info("minter value", "identifier", identifier, "value", sum.String())
+	log.Info("minter value", "project", project, "slot", slot, "value", sum.String())
 	return *sum, nil
 }
 
@@ -59,48 +97,268 @@ func (a *PgLeaderboardAggregator) Run(ctx context.Context) {
 	errch := make(chan error)
 	// create tmp table
 	createTempTable(a.db)
+	if err := SyncExclusions(a.db, a.exclusions); err != nil {
+		log.Error("failed to sync exclusions", "error", err)
+	}
 
-	scm := FullScoreCalculatorManager(&PgMinterBuyValueAggregator{
+	buyValueAggregator := &PgMinterBuyValueAggregator{
 		db: a.db,
-	})
+	}
+	rules, err := NewScoringRules(a.scoring, buyValueAggregator, a.protocolAddresses...)
+	if err != nil {
+		log.Fatal("failed to build scoring rules", "error", err)
+	}
+	scm, err := rules.Manager(FullRules)
+	if err != nil {
+		log.Error("failed to get full rule set", "error", err)
+		return
+	}
+	now := time.Now()
+	seasons := a.activeSeasons(rules, now)
+	projects := &projectRun{}
 
 	p, err := a.GetParticipants()
 	if err != nil {
 		log.Fatal("failed to get participants", "error", err)
 	}
-	for _, w := range p {
+	links, err := GetWalletLinks(a.db)
+	if err != nil {
+		log.Error("failed to get wallet links", "error", err)
+	}
+	for _, wallets := range GroupByIdentity(p, links) {
 		// add participant score to tmp table
-		go a.computeParticipantEvents(w, scm, errch)
+		go a.computeParticipantEvents(wallets, links, scm, seasons, projects, now, errch)
 		err := <-errch
 		if err != nil {
 			log.Error("failed to compute participant events", "error", err)
 		}
 	}
 
+	if err := rankTable(a.db, "tmp_leaderboard_lines", ""); err != nil {
+		log.Error("failed to rank leaderboard lines", "error", err)
+	}
+	indexTmpTable(a.db)
 	backupLeaderboardLines(a.db)
 	hotSwapTables(a.db)
+	runID := ulid.Make()
+	if err := recordScoreChanges(a.db, runID, now); err != nil {
+		log.Error("failed to record score changes", "error", err)
+	}
 	cleanupTmpTables(a.db)
+	a.saveSeasons(seasons, now)
+	if err := projects.save(a.db); err != nil {
+		log.Error("failed to save project leaderboards", "error", err)
+	}
+	if err := DeleteOrphanLineScores(a.db); err != nil {
+		log.Error("failed to delete line scores of replaced lines", "error", err)
+	}
+	if err := a.saveProtocolStats(now); err != nil {
+		log.Error("failed to save protocol stats", "error", err)
+	}
+	if err := notifyUpdate(a.db, runID); err != nil {
+		log.Error("failed to notify leaderboard update", "error", err)
+	}
 	fmt.Printf("\n")
 }
 
-func (a *PgLeaderboardAggregator) computeParticipantEvents(wallet string, scm *ScoreCalculatorManager, errch chan<- error) {
-	log.Info("computing participant events", "wallet", wallet)
-	events, err := a.GetParticipantEvents(wallet)
+// Wallets of one identity are ranked together under the first one
+func (a *PgLeaderboardAggregator) computeParticipantEvents(wallets []string, links []WalletLink, scm *ScoreCalculatorManager, seasons []*seasonRun, projects *projectRun, now time.Time, errch chan<- error) {
+	wallet := wallets[0]
+	log.Info("computing participant events", "wallet", wallet, "linked", len(wallets)-1)
+
+	events, history, err := a.identityEvents(wallets, links)
 	if err != nil {
 		log.Fatal("failed to get participant events", "error", err)
 	}
 
-	pr := NewPersonnalRanking(wallet, events)
+	pr := NewPersonnalRanking(wallet, events).WithHistory(history, time.Time{}, now).WithLinkedWallets(wallets[1:]...)
 	leaderboardLine := pr.ComputeScore(scm)
+	var holdings []ProjectHolding
+	for _, w := range wallets {
+		holdings = append(holdings, CurrentHoldings(w, pr.HistoryEvents)...)
+	}
 
-	a.db.Exec("INSERT INTO tmp_leaderboard_lines (wallet_address, points, categories, id, total_score) VALUES (?, ?, ?, ?, ?)", leaderboardLine.WalletAddress, leaderboardLine.Points, leaderboardLine.Categories, leaderboardLine.ID, leaderboardLine.TotalScore)
+	a.db.Exec("INSERT INTO tmp_leaderboard_lines (wallet_address, points, categories, id, total_score, rules_version, wallets, score, first_activity_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", leaderboardLine.WalletAddress, leaderboardLine.Points, leaderboardLine.Categories, leaderboardLine.ID, leaderboardLine.TotalScore, leaderboardLine.RulesVersion, leaderboardLine.Wallets, leaderboardLine.Score, leaderboardLine.FirstActivityAt)
+	// NOTE: line scores of previous lines are removed once lines are swapped
+	if scores := LineScores(leaderboardLine, holdings); len(scores) > 0 {
+		if err := a.db.CreateInBatches(scores, 100).Error; err != nil {
+			log.Error("failed to save line scores", "wallet", wallet, "error", err)
+		}
+	}
+
+	for _, sr := range seasons {
+		seasonEvents := sr.season.Events(events)
+		until := sr.season.EndAt
+		if now.Before(until) {
+			until = now
+		}
+		spr := NewPersonnalRanking(wallet, seasonEvents).WithHistory(history, sr.season.StartAt, until).WithLinkedWallets(wallets[1:]...)
+		line := spr.ComputeScore(sr.scm)
+		// NOTE: holders without activity during season still get points
+		if len(seasonEvents) == 0 && len(line.Points) == 0 {
+			continue
+		}
+		sr.add(SeasonLeaderboardLineFromLine(sr.season.ID, line), LineScores(line, holdings))
+	}
+
+	for _, project := range EventProjects(events) {
+		ppr := NewPersonnalRanking(wallet, ProjectEvents(project, events)).WithHistory(ProjectEvents(project, history), time.Time{}, now).WithLinkedWallets(wallets[1:]...)
+		projects.add(ProjectLeaderboardLineFromLine(project, ppr.ComputeScore(scm)))
+	}
 
 	errch <- nil
 }
 
+// Own events of identity wallets, and their full history used by history builders
+func (a *PgLeaderboardAggregator) identityEvents(wallets []string, links []WalletLink) ([]DomainEvent, []DomainEvent, error) {
+	wallet := wallets[0]
+	var events, history []DomainEvent
+	for _, w := range wallets {
+		own, err := a.GetParticipantEvents(w)
+		if err != nil {
+			return nil, nil, err
+		}
+		related, err := a.GetParticipantRelatedEvents(w, own)
+		if err != nil {
+			log.Error("failed to get participant related events", "wallet", w, "error", err)
+		}
+		referrals, err := a.GetReferralEvents(w)
+		if err != nil {
+			log.Error("failed to get participant referral events", "wallet", w, "error", err)
+		}
+		events = append(events, own...)
+		history = append(append(append(history, own...), related...), referrals...)
+	}
+	for _, l := range links {
+		if l.IdentityWallet == wallet {
+			history = append(history, WalletLinkedEvent(l))
+		}
+	}
+	// NOTE: transfers between linked wallets are both own and related events
+	return events, uniqueEvents(history), nil
+}
+
+// All-time ranking of wallet identity the way aggregator computes it
+func (a *PgLeaderboardAggregator) identityRanking(wallet string, until time.Time) (*PersonnalRanking, error) {
+	identity, err := IdentityOf(a.db, wallet)
+	if err != nil {
+		return nil, err
+	}
+	links, err := GetWalletLinks(a.db)
+	if err != nil {
+		return nil, err
+	}
+	wallets := []string{identity}
+	for _, l := range links {
+		if l.IdentityWallet == identity {
+			wallets = append(wallets, l.WalletAddress)
+		}
+	}
+	events, history, err := a.identityEvents(wallets, links)
+	if err != nil {
+		return nil, err
+	}
+	return NewPersonnalRanking(identity, events).WithHistory(history, time.Time{}, until).WithLinkedWallets(wallets[1:]...), nil
+}
+
+// Protocol stats are a full scan of domain events, they are only recomputed when chain events changed
+// since last save. Runs overlapping one that is saving them skip it
+func (a *PgLeaderboardAggregator) saveProtocolStats(now time.Time) error {
+	if !a.statsComputing.TryLock() {
+		return nil
+	}
+	defer a.statsComputing.Unlock()
+
+	w, err := GetStatsWatermark(a.db)
+	if err != nil {
+		return err
+	}
+	if w.Equal(a.stats) {
+		return nil
+	}
+	if err := SaveProtocolStats(a.db, now); err != nil {
+		return err
+	}
+	a.stats = w
+	return nil
+}
+
+// Replay all-time score of wallet identity with tracing, the way aggregator computes it
+func (a *PgLeaderboardAggregator) ExplainWallet(rules *ScoringRules, wallet string) (*LeaderboardLine, *ScoreTrace, error) {
+	scm, err := rules.Manager(FullRules)
+	if err != nil {
+		return nil, nil, err
+	}
+	pr, err := a.identityRanking(wallet, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	line, trace := pr.Explain(scm)
+	return line, trace, nil
+}
+
+// Sync configured seasons and prepare the ones that have to be (re)computed
+func (a *PgLeaderboardAggregator) activeSeasons(rules *ScoringRules, now time.Time) []*seasonRun {
+	if err := SyncSeasons(a.db, a.seasons); err != nil {
+		log.Error("failed to sync seasons", "error", err)
+	}
+	seasons, err := GetSeasons(a.db)
+	if err != nil {
+		log.Error("failed to get seasons", "error", err)
+		return nil
+	}
+
+	var runs []*seasonRun
+	for _, s := range seasons {
+		if !s.IsActive(now) {
+			continue
+		}
+		scm, err := rules.Manager(s.Rules)
+		if err != nil {
+			log.Error("failed to get season rules", "season", s.Name, "rules", s.Rules, "error", err)
+			continue
+		}
+		runs = append(runs, &seasonRun{season: s, scm: scm})
+	}
+	return runs
+}
+
+// Replace season lines and freeze seasons that are over
+func (a *PgLeaderboardAggregator) saveSeasons(seasons []*seasonRun, now time.Time) {
+	for _, sr := range seasons {
+		err := a.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("season_id = ?", sr.season.ID).Delete(&SeasonLeaderboardLine{}).Error; err != nil {
+				return err
+			}
+			if len(sr.lines) > 0 {
+				if err := tx.CreateInBatches(sr.lines, 100).Error; err != nil {
+					return err
+				}
+			}
+			if len(sr.scores) > 0 {
+				if err := tx.CreateInBatches(sr.scores, 100).Error; err != nil {
+					return err
+				}
+			}
+			if err := rankTable(tx, "season_leaderboard_lines", "l.season_id,"); err != nil {
+				return err
+			}
+			if sr.season.IsClosed(now.Add(-seasonFreezeDelay)) {
+				log.Info("freezing season", "season", sr.season.Name)
+				return tx.Model(&sr.season).Update("frozen_at", now).Error
+			}
+			return nil
+		})
+		if err != nil {
+			log.Error("failed to save season leaderboard", "season", sr.season.Name, "error", err)
+		}
+	}
+}
+
 func (a *PgLeaderboardAggregator) GetParticipants() ([]string, error) {
 	var wallets []string
-	a.db.Raw("SELECT DISTINCT wallet_address FROM domain_events").Scan(&wallets)
+	// NOTE: referrers may not have any event yet
+	a.db.Raw("SELECT DISTINCT wallet_address FROM domain_events UNION SELECT DISTINCT referrer_wallet FROM referrals").Scan(&wallets)
 	return wallets, nil
 }
 
@@ -110,17 +368,147 @@ func (a *PgLeaderboardAggregator) GetParticipantEvents(wallet string) ([]DomainE
 	return events, nil
 }
 
-func NewPgAggregrator(db *gorm.DB) *PgLeaderboardAggregator {
+// Events stored for other wallets that changed participant holdings:
+// outgoing transfers and value moved from or to tokens participant owned
+func (a *PgLeaderboardAggregator) GetParticipantRelatedEvents(wallet string, events []DomainEvent) ([]DomainEvent, error) {
+	var outgoing []DomainEvent
+	err := a.db.Where("event_name = ? AND data->>'from' = ? AND wallet_address <> ?", "project:transfer", wallet, wallet).Find(&outgoing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var tokenIds []string
+	for _, e := range append(append([]DomainEvent{}, events...), outgoing...) {
+		if e.EventName == "project:transfer" {
+			tokenIds = append(tokenIds, e.Data["token_id"])
+		}
+	}
+	if len(tokenIds) == 0 {
+		return outgoing, nil
+	}
+
+	var tokenEvents []DomainEvent
+	err = a.db.Where("event_name IN ? AND wallet_address <> ?", []string{"project:transfer-value", "project:slot-changed"}, wallet).
+		Where("data->>'from_token_id' IN ? OR data->>'to_token_id' IN ? OR data->>'token_id' IN ?", tokenIds, tokenIds, tokenIds).
+		Find(&tokenEvents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(outgoing, tokenEvents...), nil
+}
+
+// Keep first occurrence of events, synthetic events without id are always kept
+func uniqueEvents(events []DomainEvent) []DomainEvent {
+	seen := make(map[string]bool)
+	var res []DomainEvent
+	for _, e := range events {
+		if e.EventId != "" {
+			if seen[e.EventId] {
+				continue
+			}
+			seen[e.EventId] = true
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// Referral links of participant and funding events of its referees
+func (a *PgLeaderboardAggregator) GetReferralEvents(wallet string) ([]DomainEvent, error) {
+	referrals, err := GetReferees(a.db, wallet)
+	if err != nil || len(referrals) == 0 {
+		return nil, err
+	}
+
+	var referees []string
+	var events []DomainEvent
+	for _, r := range referrals {
+		referees = append(referees, r.RefereeWallet)
+		events = append(events, ReferralLinkedEvent(r))
+	}
+
+	var funding []DomainEvent
+	err = a.db.Where("event_name IN ? AND wallet_address IN ?", []string{"minter:buy", "minter:airdrop"}, referees).Find(&funding).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(events, funding...), nil
+}
+
+// Scoring configuration used to build rule sets, defaults to DefaultScoringConfig
+func WithScoring(scoring *config.Scoring) PgLeaderboardAggregatorFunc {
+	return func(opt *PgLeaderboardAggregatorOpts) {
+		opt.scoring = scoring
+	}
+}
+
+func WithSeasons(seasons ...Season) PgLeaderboardAggregatorFunc {
+	return func(opt *PgLeaderboardAggregatorOpts) {
+		opt.seasons = append(opt.seasons, seasons...)
+	}
+}
+
+// Contracts holding tokens on behalf of wallets, transfers to them are not clawed back
+func WithProtocolAddresses(addresses ...string) PgLeaderboardAggregatorFunc {
+	return func(opt *PgLeaderboardAggregatorOpts) {
+		opt.protocolAddresses = append(opt.protocolAddresses, addresses...)
+	}
+}
+
+// Configured wallets scored but hidden from ranking
+func WithExclusions(exclusions ...WalletExclusion) PgLeaderboardAggregatorFunc {
+	return func(opt *PgLeaderboardAggregatorOpts) {
+		opt.exclusions = append(opt.exclusions, exclusions...)
+	}
+}
+
+func NewPgAggregrator(db *gorm.DB, opts ...PgLeaderboardAggregatorFunc) *PgLeaderboardAggregator {
+	opt := &PgLeaderboardAggregatorOpts{scoring: DefaultScoringConfig()}
+	for _, optFn := range opts {
+		optFn(opt)
+	}
+
 	return &PgLeaderboardAggregator{
-		db: db,
+		db:                db,
+		scoring:           opt.scoring,
+		seasons:           opt.seasons,
+		protocolAddresses: opt.protocolAddresses,
+		exclusions:        opt.exclusions,
 	}
 }
 
 func createTempTable(db *gorm.DB) {
-	_ = db.AutoMigrate(&LeaderboardLine{})
+	_ = db.AutoMigrate(&LeaderboardLine{}, &Season{}, &SeasonLeaderboardLine{}, &ReferralCode{}, &Referral{}, &WalletExclusion{}, &WalletLink{}, &LineScore{}, &ProjectLeaderboardLine{}, &ScoreChange{}, &ProtocolStat{})
+	if err := EnsureUniqueLineWallets(db); err != nil {
+		log.Error("failed to add unique index on leaderboard lines", "error", err)
+	}
 	db.Exec("CREATE TABLE tmp_leaderboard_lines AS SELECT * FROM leaderboard_lines WHERE false")
 }
 
+const uniqueLineWalletsQuery = `SELECT COUNT(*) FROM pg_indexes WHERE tablename = 'leaderboard_lines'
+	AND indexdef LIKE 'CREATE UNIQUE INDEX %' AND indexdef LIKE '%(wallet_address)'`
+
+// Add unique index on wallet of all-time lines unless one already exists,
+// swapped tables keep the index created by aggregator under a name left to postgres
+func EnsureUniqueLineWallets(db *gorm.DB) error {
+	var count int64
+	if err := db.Raw(uniqueLineWalletsQuery).Scan(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Exec("CREATE UNIQUE INDEX ON leaderboard_lines (wallet_address)").Error
+}
+
+// NOTE: indexes are not copied along with table, names are left to postgres as swapped tables keep theirs
+func indexTmpTable(db *gorm.DB) {
+	db.Exec("CREATE UNIQUE INDEX ON tmp_leaderboard_lines (wallet_address)")
+	db.Exec("CREATE INDEX ON tmp_leaderboard_lines (rank)")
+	db.Exec("CREATE INDEX ON tmp_leaderboard_lines (score)")
+}
+
 func backupLeaderboardLines(db *gorm.DB) {
 	db.Exec("CREATE TEMP TABLE bck_leaderboard_lines AS SELECT * FROM leaderboard_lines")
 }
@@ -1,6 +1,8 @@
 package leaderboard
 
 import (
+	"errors"
+	"fmt"
 	"slices"
 	"time"
 
@@ -8,9 +10,12 @@ import (
 	"github.com/holiman/uint256"
 )
 
+var ErrMissingBuyValueAggregator = errors.New("missing buy value aggregator")
+
 type (
 	BuyValueAggregator interface {
-		GetMinterCurrentValue(identifier string, recordedAt time.Time) (uint256.Int, error)
+		// Sum of bought value for project (and slot if not empty) up to recordedAt
+		GetMinterCurrentValue(project string, slot string, recordedAt time.Time) (uint256.Int, error)
 	}
 	PersistBuyValue interface {
 		SaveMinterCurrentValue(identifier string, value uint256.Int) error
@@ -24,38 +29,54 @@ type BoostCalculator interface {
 }
 
 type Boost struct {
-	Name string
+	Name string `json:"name"`
 	// Fiels used to append to metadata for
 	// UI display
-	DisplayName string
-	Value       int
+	DisplayName string `json:"display_name"`
+	// Coef in percent
+	Value int `json:"value"`
 }
 
-type KarathuruFundingMilestoneBoostCalculator struct {
+// Boost buys based on how much a project has already been funded.
+// Early buyers get the highest multiplier, it decreases as minter value reaches each step.
+type FundingMilestoneBoostCalculator struct {
 	Aggregator BuyValueAggregator
-	Steps      []boostValueItem
+	Project    string
+	// Restrict boost to a single slot, empty means every slot of the project
+	Slot  string
+	Steps []boostValueItem
 }
 
-func NewKaratFundingMilestoneBoostCalculator(a BuyValueAggregator) KarathuruFundingMilestoneBoostCalculator {
-	return KarathuruFundingMilestoneBoostCalculator{
+func NewFundingMilestoneBoostCalculator(a BuyValueAggregator, project string, slot string, steps []boostValueItem) *FundingMilestoneBoostCalculator {
+	return &FundingMilestoneBoostCalculator{
 		Aggregator: a,
-		Steps: []boostValueItem{
-			{50000, 300},
-			{150000, 200},
-			{300000, 150},
-			{500000, 120},
-			{700000, 110},
-		},
+		Project:    project,
+		Slot:       slot,
+		Steps:      steps,
 	}
 }
 
-func (bc KarathuruFundingMilestoneBoostCalculator) Check(e DomainEvent) *Boost {
+// Karathuru milestones as they were before being moved to scoring configuration
+func NewKaratFundingMilestoneBoostCalculator(a BuyValueAggregator) *FundingMilestoneBoostCalculator {
+	return NewFundingMilestoneBoostCalculator(a, "Karathuru", "", []boostValueItem{
+		{50000, 300},
+		{150000, 200},
+		{300000, 150},
+		{500000, 120},
+		{700000, 110},
+	})
+}
+
+func (bc *FundingMilestoneBoostCalculator) Check(e DomainEvent) *Boost {
 	projectName := e.Metadata["project_name"]
-	if projectName != "Karathuru" || !slices.ContainsFunc([]string{"minter:buy", "minter:airdrop"}, func(s string) bool { return s == e.EventName }) {
+	if projectName != bc.Project || !slices.ContainsFunc([]string{"minter:buy", "minter:airdrop"}, func(s string) bool { return s == e.EventName }) {
+		return nil
+	}
+	if bc.Slot != "" && e.Metadata["slot"] != bc.Slot {
 		return nil
 	}
 
-	return &Boost{Name: "KarathuruFundingMilestone"}
+	return &Boost{Name: "FundingMilestone"}
 }
 
 func mulCoeficient(value *uint256.Int, coef *uint256.Int) *uint256.Int {
@@ -66,20 +87,28 @@ func mulCoeficient(value *uint256.Int, coef *uint256.Int) *uint256.Int {
 	return &total
 }
 
-func (bc KarathuruFundingMilestoneBoostCalculator) Apply(e DomainEvent, b *Boost, s *Score) *Score {
-	mv, err := bc.Aggregator.GetMinterCurrentValue("Karathuru", e.RecordedAt)
-	if err != nil {
-		log.Error("error getting minter value", "error", err)
-		return nil
+// Get minter value of the project when event was recorded
+func (bc *FundingMilestoneBoostCalculator) CurrentValue(e DomainEvent) (uint256.Int, error) {
+	if bc.Aggregator == nil {
+		return uint256.Int{}, ErrMissingBuyValueAggregator
 	}
+	return bc.Aggregator.GetMinterCurrentValue(bc.Project, bc.Slot, e.RecordedAt)
+}
+
+func (bc *FundingMilestoneBoostCalculator) Apply(e DomainEvent, b *Boost, s *Score) *Score {
 	if s.Rule != AmountFundRuleName {
 		return nil
 	}
+	mv, err := bc.CurrentValue(e)
+	if err != nil {
+		log.Error("error getting minter value", "error", err)
+		return nil
+	}
 
 	for _, v := range bc.Steps {
 		if mv.Cmp(uint256.NewInt(uint64(v.step))) <= 0 {
 			s.Points = mulCoeficient(s.Points, uint256.NewInt(v.coef))
-			b.DisplayName = "Funding Karathuru"
+			b.DisplayName = fmt.Sprintf("Funding %s", bc.Project)
 			b.Value = int(v.coef)
 			s.Boosts = append(s.Boosts, *b)
 			return s
@@ -89,8 +118,23 @@ func (bc KarathuruFundingMilestoneBoostCalculator) Apply(e DomainEvent, b *Boost
 	return s
 }
 
-func (bc KarathuruFundingMilestoneBoostCalculator) GetInterval(value uint64) (boost *uint256.Int, next *uint256.Int, nextBoost *uint256.Int) {
-	return uint256.NewInt(0), uint256.NewInt(0), uint256.NewInt(0)
+// value is the project minter value. Returns current boost, minter value at which it ends and the boost that follows
+func (bc *FundingMilestoneBoostCalculator) GetInterval(value uint64) (boost *uint256.Int, next *uint256.Int, nextBoost *uint256.Int) {
+	boost = uint256.NewInt(0)
+	next = uint256.NewInt(0)
+	nextBoost = uint256.NewInt(0)
+
+	for i, v := range bc.Steps {
+		if value <= v.step {
+			boost = uint256.NewInt(v.coef)
+			next = uint256.NewInt(v.step)
+			if i+1 < len(bc.Steps) {
+				nextBoost = uint256.NewInt(bc.Steps[i+1].coef)
+			}
+			break
+		}
+	}
+	return boost, next, nextBoost
 }
 
 type boostValueItem struct {
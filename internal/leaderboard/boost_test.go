@@ -13,7 +13,7 @@ type givenValueMinterValueAggregator struct {
 	value *uint256.Int
 }
 
-func (a *givenValueMinterValueAggregator) GetMinterCurrentValue(identifier string, recordedAt time.Time) (uint256.Int, error) {
+func (a *givenValueMinterValueAggregator) GetMinterCurrentValue(project string, slot string, recordedAt time.Time) (uint256.Int, error) {
 	return *a.value, nil
 }
 
@@ -59,8 +59,34 @@ var projectBoostInterval = []struct {
 	{value: 5500, boost: 300, next: 0, nextBoost: 0},
 }
 
+var milestoneInterval = []struct {
+	value     uint64
+	boost     uint64
+	next      uint64
+	nextBoost uint64
+}{
+	{value: 0, boost: 300, next: 50000, nextBoost: 200},
+	{value: 50000, boost: 300, next: 50000, nextBoost: 200},
+	{value: 74109, boost: 200, next: 150000, nextBoost: 150},
+	{value: 600000, boost: 110, next: 700000, nextBoost: 0},
+	{value: 800000, boost: 0, next: 0, nextBoost: 0},
+}
+
+var stackingData = []struct {
+	policy   leaderboard.StackingPolicy
+	expected string
+	capCoef  uint64
+	capped   bool
+}{
+	{policy: leaderboard.MultiplicativeStacking, expected: "600"},
+	{policy: leaderboard.AdditiveStacking, expected: "400"},
+	{policy: leaderboard.MaxStacking, expected: "300"},
+	{policy: leaderboard.MultiplicativeStacking, capCoef: 400, expected: "400", capped: true},
+	{policy: leaderboard.MaxStacking, capCoef: 400, expected: "300"},
+}
+
 var _ = Describe("Boost", func() {
-	Context("KarathuruFundingMilestoneBoostCalculator", func() {
+	Context("FundingMilestoneBoostCalculator", func() {
 		When("I check if a boost exists", func() {
 			It("boost only Karaturu project", func() {
 				boost := leaderboard.NewKaratFundingMilestoneBoostCalculator(newGivenValueMinterValueAggregator(1000000))
@@ -112,6 +138,28 @@ var _ = Describe("Boost", func() {
 		})
 	})
 
+	Context("FundingMilestoneBoostCalculator configured for any project", func() {
+		It("should restrict boost to configured slot", func() {
+			boost := leaderboard.NewFundingMilestoneBoostCalculator(newGivenValueMinterValueAggregator(0), "Banegas Farm", "0x2", nil)
+			buy := buyProjectEvt("Banegas Farm", 100)
+			Expect(boost.Check(buy)).To(BeNil())
+
+			buy.Metadata["slot"] = "0x2"
+			Expect(boost.Check(buy)).NotTo(BeNil())
+		})
+
+		It("should not boost without minter value aggregator", func() {
+			boost := leaderboard.NewKaratFundingMilestoneBoostCalculator(nil)
+			buy := buyProjectEvt("Karathuru", 100)
+			_, err := boost.CurrentValue(buy)
+			Expect(err).To(MatchError(leaderboard.ErrMissingBuyValueAggregator))
+		})
+
+		for _, v := range milestoneInterval {
+			It("should get proper interval", testMilestoneInterval(v.value, v.boost, v.next, v.nextBoost))
+		}
+	})
+
 	Context("ProjectValueBoostCalculator", func() {
 		It("should apply to minter events", func() {
 			boost := &leaderboard.ProjectValueBoostCalculator{}
@@ -159,12 +207,52 @@ var _ = Describe("Boost", func() {
 			Expect(len(ll.Points)).To(Equal(2))
 			for _, p := range ll.Points {
 				if p.Rule == string(leaderboard.AmountFundRuleName) {
-					Expect(p.Metadata["boosts"]).To(Equal("x2.0 - Funding Karathuru // x3.0 - Funding Value"))
+					Expect(leaderboard.BoostsDisplay(p.Breakdown.Boosts)).To(Equal("x2.0 - Funding Karathuru // x3.0 - Funding Value"))
+					Expect(p.Breakdown.Base).To(Equal(11000 * 1000000))
+					Expect(p.Breakdown.Final).To(Equal(66000 * 1000000))
 				} else {
-					Expect(p.Metadata["boosts"]).To(Equal(""))
+					Expect(p.Breakdown.Boosts).To(BeEmpty())
 				}
 			}
 		})
+
+		It("should apply multiplicative boosts sequentially", func() {
+			buy := buyProjectEvt("Karathuru", 900*1000000)
+			scm := leaderboard.NewScoreCalculatorManager(
+				leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}),
+				leaderboard.WithBoosters(leaderboard.NewKaratFundingMilestoneBoostCalculator(newGivenValueMinterValueAggregator(74109)), leaderboard.DefaultProjectValueBoostCalculator()),
+			)
+
+			// funding milestone x2 pushes points above project value x2 step
+			line := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{buy}).ComputeScore(scm)
+			Expect(line.TotalScore).To(Equal("3600"))
+		})
+
+		It("should not depend on boosters order with additive stacking", func() {
+			buy := buyProjectEvt("Karathuru", 900*1000000)
+			aggregator := newGivenValueMinterValueAggregator(74109)
+			scm := leaderboard.NewScoreCalculatorManager(
+				leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}),
+				leaderboard.WithBoosters(leaderboard.NewKaratFundingMilestoneBoostCalculator(aggregator), leaderboard.DefaultProjectValueBoostCalculator()),
+				leaderboard.WithStacking(leaderboard.AdditiveStacking, 0),
+			)
+			reversed := leaderboard.NewScoreCalculatorManager(
+				leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}),
+				leaderboard.WithBoosters(leaderboard.DefaultProjectValueBoostCalculator(), leaderboard.NewKaratFundingMilestoneBoostCalculator(aggregator)),
+				leaderboard.WithStacking(leaderboard.AdditiveStacking, 0),
+			)
+
+			line := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{buy}).ComputeScore(scm)
+			reversedLine := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{buy}).ComputeScore(reversed)
+
+			// x2 and x1.5 on base points give x2.5
+			Expect(line.TotalScore).To(Equal("2250"))
+			Expect(reversedLine.TotalScore).To(Equal(line.TotalScore))
+		})
+
+		for _, v := range stackingData {
+			It("should stack boosts according to policy", testStacking(v.policy, v.capCoef, v.expected, v.capped))
+		}
 	})
 })
 
@@ -180,6 +268,26 @@ func testKarathuruMilestone(value uint64, expected string) func() {
 	}
 }
 
+func testMilestoneInterval(value uint64, boost uint64, next uint64, nextBoost uint64) func() {
+	return func() {
+		bc := leaderboard.NewKaratFundingMilestoneBoostCalculator(newGivenValueMinterValueAggregator(value))
+
+		b, n, nb := bc.GetInterval(value)
+		Expect(b).To(Equal(uint256.NewInt(boost)))
+		Expect(n).To(Equal(uint256.NewInt(next)))
+		Expect(nb).To(Equal(uint256.NewInt(nextBoost)))
+	}
+}
+
+func testStacking(policy leaderboard.StackingPolicy, capCoef uint64, expected string, capped bool) func() {
+	return func() {
+		boosts := []leaderboard.Boost{{Value: 200}, {Value: 300}}
+		points, c := policy.Stack(uint256.NewInt(100), boosts, capCoef)
+		Expect(points.String()).To(Equal(expected))
+		Expect(c).To(Equal(capped))
+	}
+}
+
 func testProperInterval(value uint64, boost uint64, next uint64, nextBoost uint64) func() {
 	return func() {
 		bc := leaderboard.DefaultProjectValueBoostCalculator()
@@ -0,0 +1,177 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+)
+
+// Transfer clawback - Remove points granted on funding when bought value leaves the wallet.
+// Burns and transfers to protocol contracts (yielder, offseter, migrator) are not clawed back.
+type TransferClawbackScoreCalculator struct {
+	// Addresses tokens can be sent to without losing points
+	Excluded []string
+}
+
+func (sc *TransferClawbackScoreCalculator) isExcluded(address string) bool {
+	if starknet.SameAddress(address, "0x0") {
+		return true
+	}
+	for _, a := range sc.Excluded {
+		if starknet.SameAddress(a, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// Destination keeps points within the identity: same or linked wallet, burn or excluded protocol contract.
+// Unknown destinations (empty address) do not keep points
+func (sc *TransferClawbackScoreCalculator) keeps(wallet string, to string, linked map[string]bool) bool {
+	if to == "" {
+		return false
+	}
+	if starknet.SameAddress(to, wallet) || sc.isExcluded(to) {
+		return true
+	}
+	return linked[normalizeFelt(wallet)] && linked[normalizeFelt(to)]
+}
+
+// Rules whose points are taken back when funded value leaves the wallet
+var clawedBackRules = []RuleName{AmountFundRuleName, EarlyAdopterRuleName, NumberOfProjectsRuleName}
+
+// Points granted to wallet by clawed back rules, indexed by event they were earned with
+func grantedScores(wallet string, score []Score) map[string][]Score {
+	grants := make(map[string][]Score)
+	for _, s := range score {
+		if s.Negative || s.Points == nil || s.Event.Metadata["project_name"] == "" || !starknet.SameAddress(s.Wallet, wallet) {
+			continue
+		}
+		for _, r := range clawedBackRules {
+			if s.Rule == r {
+				grants[s.Event.EventId] = append(grants[s.Event.EventId], s)
+				break
+			}
+		}
+	}
+	return grants
+}
+
+func addProjectValue(values map[string]*u256.Int, project string, value *u256.Int) {
+	if _, exists := values[project]; !exists {
+		values[project] = u256.NewInt(0)
+	}
+	values[project].Add(values[project], value)
+}
+
+// Take back granted points in proportion of funded value leaving the wallet.
+// Moved value is capped to funded value not clawed back yet
+func takeBack(project string, value *u256.Int, funded map[string]*u256.Int, granted map[string]*u256.Int) *u256.Int {
+	f, exists := funded[project]
+	if !exists || f.IsZero() || value.IsZero() {
+		return nil
+	}
+	var moved u256.Int
+	moved.Set(value)
+	if moved.Cmp(f) > 0 {
+		moved.Set(f)
+	}
+
+	var points u256.Int
+	if g, exists := granted[project]; exists {
+		points.Mul(g, &moved)
+		points.Div(&points, f)
+		g.Sub(g, &points)
+	}
+	f.Sub(f, &moved)
+	return &points
+}
+
+// Without computed scores no granted points are known, nothing is clawed back
+func (sc *TransferClawbackScoreCalculator) ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time) []Score {
+	return sc.ComputeScoredHistory(wallet, events, from, until, nil)
+}
+
+// Clawback is the share of points granted on a project (boosted funding, early adopter and number of projects)
+// matching the share of funded value that leaves the wallet, either by sending an owned token
+// or by moving value out of it with transfer-value. Only value minted to wallet is funded,
+// sending received tokens or value never costs points.
+func (sc *TransferClawbackScoreCalculator) ComputeScoredHistory(wallet string, events []DomainEvent, from time.Time, until time.Time, score []Score) []Score {
+	ledger := newTokenLedger(wallet)
+	// tokens sent between wallets of the same identity stay with the user
+	linked := linkedWallets(events)
+	grants := grantedScores(wallet, score)
+	// funded value and granted points not clawed back yet, indexed by project
+	funded := make(map[string]*u256.Int)
+	granted := make(map[string]*u256.Int)
+
+	var scores []Score
+	for _, e := range events {
+		if e.RecordedAt.After(until) {
+			break
+		}
+		for _, g := range grants[e.EventId] {
+			addProjectValue(granted, g.Event.Metadata["project_name"], g.Points)
+		}
+		delete(grants, e.EventId)
+
+		var tok *heldToken
+		var value *u256.Int
+		switch e.EventName {
+		case "minter:buy", "minter:airdrop", "migrator:migration":
+			if !starknet.SameAddress(e.WalletAddress, wallet) {
+				break
+			}
+			v, err := u256.FromHex(e.Data["value"])
+			if err != nil {
+				break
+			}
+			addProjectValue(funded, e.Metadata["project_name"], v)
+		case "project:transfer":
+			if !starknet.SameAddress(e.Data["from"], wallet) || sc.keeps(wallet, e.Data["to"], linked) {
+				break
+			}
+			if t := ledger.token(e, e.Data["token_id"]); t.owned {
+				tok, value = t, new(u256.Int).Set(t.funded)
+			}
+		case "project:transfer-value":
+			fromId := e.Data["from_token_id"]
+			if starknet.HexStringToUint64(fromId) == 0 {
+				break
+			}
+			src := ledger.token(e, fromId)
+			dst := ledger.token(e, e.Data["to_token_id"])
+			if !src.owned || dst.owned || sc.keeps(wallet, dst.owner, linked) {
+				break
+			}
+			v, err := u256.FromHex(e.Data["value"])
+			if err != nil {
+				break
+			}
+			tok, value = src, src.fundedShare(v)
+		}
+
+		if tok != nil {
+			points := takeBack(tok.project, value, funded, granted)
+			if points != nil && !points.IsZero() && !e.RecordedAt.Before(from) {
+				scores = append(scores, Score{
+					Points:   points,
+					Negative: true,
+					Rule:     TransferClawbackRuleName,
+					Event: DomainEvent{
+						RecordedAt:    e.RecordedAt,
+						EventId:       e.EventId,
+						EventName:     e.EventName,
+						FromAddress:   e.FromAddress,
+						WalletAddress: wallet,
+						Data:          e.Data,
+						Metadata:      EventMetadata{"project_name": tok.project, "slot": tok.slot},
+					},
+				})
+			}
+		}
+		ledger.apply(e)
+	}
+	return scores
+}
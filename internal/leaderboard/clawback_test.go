@@ -0,0 +1,148 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/holiman/uint256"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const protocol = "0x29566cc83b15256c6d831f0181fe84c2a3f97fcc8ba1612b2c6584cccc8a0b4"
+
+func clawbackScore(events []leaderboard.DomainEvent) *leaderboard.LeaderboardLine {
+	scm := leaderboard.NewScoreCalculatorManager(
+		leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}),
+		leaderboard.WithHistoryBuilders(&leaderboard.TransferClawbackScoreCalculator{Excluded: []string{protocol}}),
+	)
+	return leaderboard.NewPersonnalRanking(holder, events).WithHistory(events, time.Time{}, time.Unix(t0+10*day, 0)).ComputeScore(scm)
+}
+
+func farmingEvt(name string, value string, ts int64) leaderboard.DomainEvent {
+	return newEvent(name+value, name, map[string]string{"address": holder, "value": value}, map[string]string{}, []string{}, ts)
+}
+
+var _ = Describe("Negative scores", func() {
+	// 1000$
+	value := "0x3b9aca00"
+
+	It("should never go below zero", func() {
+		total := leaderboard.TotalScore([]leaderboard.Score{
+			{Points: uint256.NewInt(1000000), Rule: leaderboard.AmountFundRuleName},
+			{Points: uint256.NewInt(3000000), Rule: leaderboard.TransferClawbackRuleName, Negative: true},
+			{Points: uint256.NewInt(2000000), Rule: leaderboard.AmountFundRuleName},
+		})
+		Expect(total.String()).To(Equal("0"))
+	})
+
+	Context("TransferClawbackScoreCalculator", func() {
+		It("should claw back funded points when token is sent away", func() {
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), transferTokenEvt("0x1", buyer, t0+day))
+			line := clawbackScore(events)
+
+			Expect(line.TotalScore).To(Equal("0"))
+			Expect(line.Points[len(line.Points)-1].Value).To(Equal(-1000 * 1000000))
+		})
+
+		It("should not claw back burns and protocol deposits", func() {
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), transferTokenEvt("0x1", protocol, t0+day))
+			Expect(clawbackScore(events).TotalScore).To(Equal("1000"))
+
+			events = append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), transferTokenEvt("0x1", "0x0", t0+day))
+			Expect(clawbackScore(events).TotalScore).To(Equal("1000"))
+		})
+
+		It("should not claw back more than what was funded", func() {
+			// token was received, not bought
+			events := append(mintTokenEvts("0x1", value, t0), transferTokenEvt("0x1", buyer, t0+day))
+			line := clawbackScore(events)
+			Expect(line.TotalScore).To(Equal("0"))
+			Expect(line.Points).To(BeEmpty())
+		})
+	})
+
+	Context("Clawback of granted points", func() {
+		scm := leaderboard.NewScoreCalculatorManager(
+			leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}, &leaderboard.NumberOfProjectsScoreCalculator{}, &leaderboard.EarlyAdopterScoreCalculator{}),
+			leaderboard.WithBoosters(leaderboard.DefaultProjectValueBoostCalculator()),
+			leaderboard.WithHistoryBuilders(&leaderboard.TransferClawbackScoreCalculator{Excluded: []string{protocol}}),
+		)
+		score := func(events []leaderboard.DomainEvent) *leaderboard.LeaderboardLine {
+			return leaderboard.NewPersonnalRanking(holder, events).WithHistory(events, time.Time{}, time.Unix(t0+10*day, 0)).ComputeScore(scm)
+		}
+
+		It("should claw back boosted, early adopter and number of projects points", func() {
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000))
+			// x2 funding value boost, 200 points for project and 200 for early adopter
+			Expect(score(events).TotalScore).To(Equal("2400"))
+
+			events = append(events, transferTokenEvt("0x1", buyer, t0+day))
+			line := score(events)
+			Expect(line.TotalScore).To(Equal("0"))
+			Expect(line.Points[len(line.Points)-1].Value).To(Equal(-2400 * 1000000))
+		})
+
+		It("should claw back value moved out of an owned token", func() {
+			half := newProjectTransferValueEvt("transfer_value_out", map[string]string{"from_token_id": "0x1", "to_token_id": "0x2", "value": "0x1dcd6500"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, t0+day)
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), half)
+			Expect(score(events).TotalScore).To(Equal("1200"))
+		})
+
+		It("should not claw back received tokens sent away", func() {
+			metadata := map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}
+			mintToBuyer := newProjectTransferEvt("transfer_0x2", map[string]string{"from": "0x0", "to": buyer, "token_id": "0x2"}, metadata, []string{}, t0)
+			mintValue := newProjectTransferValueEvt("transfer_value_0x2", map[string]string{"from_token_id": "0x0", "to_token_id": "0x2", "value": value}, metadata, t0)
+			received := newProjectTransferEvt("transfer_in_0x2", map[string]string{"from": buyer, "to": holder, "token_id": "0x2"}, metadata, []string{}, t0+day)
+			sentBack := newProjectTransferEvt("transfer_out_0x2", map[string]string{"from": holder, "to": buyer, "token_id": "0x2"}, metadata, []string{}, t0+2*day)
+
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), mintToBuyer, mintValue, received, sentBack)
+			line := score(events)
+			Expect(line.TotalScore).To(Equal("2400"))
+			for _, p := range line.Points {
+				Expect(p.Rule).NotTo(Equal(string(leaderboard.TransferClawbackRuleName)))
+			}
+		})
+
+		It("should only claw back funded share of value moved out of a token holding received value", func() {
+			metadata := map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}
+			// 1000$ received from another wallet token merged into bought token
+			mintToBuyer := newProjectTransferEvt("transfer_0x2", map[string]string{"from": "0x0", "to": buyer, "token_id": "0x2"}, metadata, []string{}, t0)
+			mintValue := newProjectTransferValueEvt("transfer_value_0x2", map[string]string{"from_token_id": "0x0", "to_token_id": "0x2", "value": value}, metadata, t0)
+			merge := newProjectTransferValueEvt("transfer_value_in", map[string]string{"from_token_id": "0x2", "to_token_id": "0x1", "value": value}, metadata, t0+day)
+			// half of the 2000$ token leaves, half of it was funded
+			out := newProjectTransferValueEvt("transfer_value_out", map[string]string{"from_token_id": "0x1", "to_token_id": "0x3", "value": value}, metadata, t0+2*day)
+
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), mintToBuyer, mintValue, merge, out)
+			Expect(score(events).TotalScore).To(Equal("1200"))
+		})
+
+		It("should not claw back value moved to a protocol token", func() {
+			deposit := newProjectTransferValueEvt("transfer_value_out", map[string]string{"from_token_id": "0x1", "to_token_id": "0x2", "value": value}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, t0+day)
+			mint := newProjectTransferEvt("transfer_protocol", map[string]string{"from": "0x0", "to": protocol, "token_id": "0x2"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, []string{}, t0+day)
+			events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), mint, deposit)
+			Expect(score(events).TotalScore).To(Equal("2400"))
+		})
+	})
+
+	Context("Farming deposit and withdraw", func() {
+		scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithBuilders(&leaderboard.FarmingDepositScoreCalculator{}, &leaderboard.FarmingWithdrawScoreCalculator{}))
+
+		It("should reward deposits and take them back on withdraw", func() {
+			events := []leaderboard.DomainEvent{farmingEvt("yielder:deposit", value, t0)}
+			line := leaderboard.NewPersonnalRanking(holder, events).ComputeScore(scm)
+			Expect(line.TotalScore).To(Equal("100"))
+			Expect(line.Categories.Farming).To(Equal("100"))
+
+			events = append(events, farmingEvt("yielder:withdraw", "0x1dcd6500", t0+day))
+			line = leaderboard.NewPersonnalRanking(holder, events).ComputeScore(scm)
+			Expect(line.TotalScore).To(Equal("50"))
+		})
+
+		It("should cap withdraw penalty to deposit rewards", func() {
+			events := []leaderboard.DomainEvent{farmingEvt("yielder:withdraw", value, t0)}
+			line := leaderboard.NewPersonnalRanking(holder, events).ComputeScore(scm)
+			Expect(line.Points).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	ExclusionSourceConfig   string = "config"
+	ExclusionSourceContract string = "contract"
+	ExclusionSourceAdmin    string = "admin"
+)
+
+var (
+	ErrInvalidExclusion  = errors.New("exclusion needs a valid wallet address and a reason")
+	ErrExclusionNotFound = errors.New("exclusion not found")
+	ErrExclusionManaged  = errors.New("exclusion is managed by configuration")
+)
+
+// Excluded wallets are still scored but hidden from ranking, ranks are updated as soon as exclusions change
+type WalletExclusion struct {
+	CreatedAt     time.Time `json:"created_at"`
+	WalletAddress string    `gorm:"unique" json:"wallet_address"`
+	Reason        string    `json:"reason"`
+	Source        string    `json:"source"`
+	Operator      string    `json:"operator,omitempty"`
+	ID            ulid.ULID `gorm:"primaryKey" json:"id"`
+}
+
+func normalizeWallet(wallet string) (string, bool) {
+	a, err := starknet.NewAddress(wallet)
+	if err != nil {
+		return "", false
+	}
+	return a.String(), true
+}
+
+// Configured exclusions and every configured contract, contracts never rank
+func ExclusionsFromConfig(exclusions []config.Exclusion, contracts []config.Contract) []WalletExclusion {
+	var res []WalletExclusion
+	for _, e := range exclusions {
+		if wallet, ok := normalizeWallet(e.Address); ok {
+			res = append(res, WalletExclusion{WalletAddress: wallet, Reason: e.Reason, Source: ExclusionSourceConfig})
+		}
+	}
+	for _, c := range contracts {
+		if wallet, ok := normalizeWallet(c.Address); ok {
+			res = append(res, WalletExclusion{WalletAddress: wallet, Reason: "contract " + c.Name, Source: ExclusionSourceContract})
+		}
+	}
+	return res
+}
+
+// Replace exclusions coming from configuration, exclusions added through admin api are kept
+func SyncExclusions(db *gorm.DB, exclusions []WalletExclusion) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source IN ?", []string{ExclusionSourceConfig, ExclusionSourceContract}).Delete(&WalletExclusion{}).Error; err != nil {
+			return err
+		}
+		for _, e := range exclusions {
+			var count int64
+			if err := tx.Model(&WalletExclusion{}).Where("wallet_address = ?", e.WalletAddress).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			e.CreatedAt = time.Now()
+			e.ID = ulid.Make()
+			if err := tx.Create(&e).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func ExcludeWallet(db *gorm.DB, wallet string, reason string, operator string) (*WalletExclusion, error) {
+	address, ok := normalizeWallet(wallet)
+	if !ok || reason == "" {
+		return nil, ErrInvalidExclusion
+	}
+
+	var existing WalletExclusion
+	err := db.Where("wallet_address = ?", address).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	e := WalletExclusion{CreatedAt: time.Now(), WalletAddress: address, Reason: reason, Source: ExclusionSourceAdmin, Operator: operator, ID: ulid.Make()}
+	if err := db.Create(&e).Error; err != nil {
+		return nil, err
+	}
+	if err := UpdateRanks(db); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Only exclusions added through admin api can be removed, configured ones have to be removed from configuration
+func IncludeWallet(db *gorm.DB, wallet string) error {
+	address, ok := normalizeWallet(wallet)
+	if !ok {
+		return ErrInvalidExclusion
+	}
+
+	var existing WalletExclusion
+	err := db.Where("wallet_address = ?", address).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrExclusionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if existing.Source != ExclusionSourceAdmin {
+		return ErrExclusionManaged
+	}
+	if err := db.Delete(&existing).Error; err != nil {
+		return err
+	}
+	return UpdateRanks(db)
+}
+
+func GetExclusions(db *gorm.DB) ([]WalletExclusion, error) {
+	exclusions := []WalletExclusion{}
+	err := db.Order("created_at DESC").Find(&exclusions).Error
+	return exclusions, err
+}
@@ -0,0 +1,177 @@
+package leaderboard
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	u256 "github.com/holiman/uint256"
+)
+
+const (
+	BuilderApplied     string = "applied"
+	BuilderSkipped     string = "skipped"
+	BuilderNoScore     string = "no_score"
+	defaultSkipReason  string = "event not supported"
+	defaultEmptyReason string = "builder computed no points for event"
+)
+
+// Builders able to tell why they did not support an event
+type ScoreBuilderExplainer interface {
+	SkipReason(e DomainEvent, score []Score) string
+}
+
+// Boosters able to tell which inputs they used on an event
+type BoostExplainer interface {
+	Inputs(e DomainEvent, s *Score) map[string]string
+}
+
+// How a wallet score was computed, event by event. Points are in 10^6 units until total
+type ScoreTrace struct {
+	Events  []EventTrace
+	History []BuilderTrace
+	// Sum of positive and negative points before division
+	Positive string
+	Negative string
+	Total    string
+}
+
+type EventTrace struct {
+	Event    DomainEvent
+	Builders []BuilderTrace
+}
+
+type BuilderTrace struct {
+	Builder string
+	Status  string
+	Reason  string
+	Rule    RuleName
+	Event   DomainEvent
+	// Points before boosts and once boosts are stacked
+	Base     string
+	Points   string
+	Stacking StackingPolicy
+	Capped   bool
+	Negative bool
+	Boosts   []BoosterTrace
+}
+
+type BoosterTrace struct {
+	Booster string
+	Fired   bool
+	Coef    int
+	Inputs  map[string]string
+}
+
+// Name of builder or booster type without package and suffix
+func componentName(c any, suffix string) string {
+	t := reflect.TypeOf(c)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), suffix)
+}
+
+func (t *ScoreTrace) event(e DomainEvent) *EventTrace {
+	t.Events = append(t.Events, EventTrace{Event: e})
+	return &t.Events[len(t.Events)-1]
+}
+
+func (t *ScoreTrace) total(score []Score) {
+	positive, negative := u256.NewInt(0), u256.NewInt(0)
+	for _, s := range score {
+		if s.Negative {
+			negative.Add(negative, s.Points)
+		} else {
+			positive.Add(positive, s.Points)
+		}
+	}
+	t.Positive = positive.String()
+	t.Negative = negative.String()
+	t.Total = TotalScore(score).String()
+}
+
+func skipReason(b ScoreBuilder, e DomainEvent, score []Score) string {
+	if explainer, ok := b.(ScoreBuilderExplainer); ok {
+		return explainer.SkipReason(e, score)
+	}
+	return defaultSkipReason
+}
+
+func appliedTrace(builder string, s *Score) BuilderTrace {
+	return BuilderTrace{
+		Builder:  builder,
+		Status:   BuilderApplied,
+		Rule:     s.Rule,
+		Event:    s.Event,
+		Base:     s.Base.String(),
+		Points:   s.Points.String(),
+		Stacking: s.Stacking,
+		Capped:   s.Capped,
+		Negative: s.Negative,
+	}
+}
+
+func boosterTrace(c BoostCalculator, e DomainEvent, s *Score, res *Score) BoosterTrace {
+	bt := BoosterTrace{Booster: componentName(c, "BoostCalculator")}
+	if res != nil && len(res.Boosts) > 0 {
+		bt.Fired = true
+		bt.Coef = res.Boosts[len(res.Boosts)-1].Value
+	}
+	if explainer, ok := c.(BoostExplainer); ok {
+		var base u256.Int
+		base.Set(s.Points)
+		bt.Inputs = explainer.Inputs(e, &Score{Points: &base, Rule: s.Rule, Event: e})
+	}
+	return bt
+}
+
+// Explain how line of ranking is computed, scores are computed the same way as ComputeScore
+func (pr *PersonnalRanking) Explain(scm *ScoreCalculatorManager) (*LeaderboardLine, *ScoreTrace) {
+	trace := &ScoreTrace{}
+	traced := *scm
+	traced.trace = trace
+	line := pr.ComputeScore(&traced)
+	return line, trace
+}
+
+func (sc *NumberOfProjectsScoreCalculator) SkipReason(e DomainEvent, score []Score) string {
+	pName, exists := e.Metadata["project_name"]
+	if !exists {
+		return "event has no project"
+	}
+	if ruleWasApplied(NumberOfProjectsRuleName, pName, score) {
+		return fmt.Sprintf("ruleWasApplied: %s already scored for %s", NumberOfProjectsRuleName, pName)
+	}
+	return defaultSkipReason
+}
+
+func (sc *EarlyAdopterScoreCalculator) SkipReason(e DomainEvent, score []Score) string {
+	pName, exists := e.Metadata["project_name"]
+	if !exists {
+		return "event has no project"
+	}
+	return fmt.Sprintf("%s is not an early adopter project", pName)
+}
+
+func (bc *FundingMilestoneBoostCalculator) Inputs(e DomainEvent, s *Score) map[string]string {
+	inputs := map[string]string{"project": bc.Project, "slot": bc.Slot}
+	mv, err := bc.CurrentValue(e)
+	if err != nil {
+		inputs["error"] = err.Error()
+		return inputs
+	}
+	inputs["minter_value"] = mv.Dec()
+	var steps []string
+	for _, v := range bc.Steps {
+		steps = append(steps, fmt.Sprintf("%d:%d", v.step, v.coef))
+	}
+	inputs["steps"] = strings.Join(steps, ",")
+	return inputs
+}
+
+func (bc *ProjectValueBoostCalculator) Inputs(e DomainEvent, s *Score) map[string]string {
+	var value u256.Int
+	value.Div(s.Points, u256.NewInt(1000000))
+	return map[string]string{"value": value.Dec()}
+}
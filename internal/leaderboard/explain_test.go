@@ -0,0 +1,67 @@
+package leaderboard_test
+
+import (
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func builderTrace(traces []leaderboard.BuilderTrace, builder string) *leaderboard.BuilderTrace {
+	for i := range traces {
+		if traces[i].Builder == builder {
+			return &traces[i]
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Score explanation", func() {
+	buys := func() []leaderboard.DomainEvent {
+		return []leaderboard.DomainEvent{buyProjectEvt("Karathuru", 100*1000000), buyProjectEvt("Karathuru", 100*1000000)}
+	}
+
+	It("should compute the same line as ranking", func() {
+		scm := leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(50000))
+		ranked := leaderboard.NewPersonnalRanking(holder, buys()).ComputeScore(scm)
+		explained, trace := leaderboard.NewPersonnalRanking(holder, buys()).Explain(scm)
+
+		Expect(explained.TotalScore).To(Equal(ranked.TotalScore))
+		Expect(trace.Total).To(Equal(ranked.TotalScore))
+		Expect(trace.Events).To(HaveLen(2))
+		Expect(trace.Negative).To(Equal("0"))
+	})
+
+	It("should tell why builders were skipped", func() {
+		scm := leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(50000))
+		_, trace := leaderboard.NewPersonnalRanking(holder, buys()).Explain(scm)
+
+		first := builderTrace(trace.Events[0].Builders, "NumberOfProjects")
+		Expect(first.Status).To(Equal(leaderboard.BuilderApplied))
+		second := builderTrace(trace.Events[1].Builders, "NumberOfProjects")
+		Expect(second.Status).To(Equal(leaderboard.BuilderSkipped))
+		Expect(second.Reason).To(ContainSubstring("ruleWasApplied"))
+		Expect(builderTrace(trace.Events[0].Builders, "Offseter").Reason).To(Equal("event not supported"))
+	})
+
+	It("should record boosters with their inputs", func() {
+		scm := leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(50000))
+		_, trace := leaderboard.NewPersonnalRanking(holder, buys()).Explain(scm)
+
+		funded := builderTrace(trace.Events[0].Builders, "AmountFunded")
+		Expect(funded.Base).To(Equal("100000000"))
+		Expect(funded.Points).To(Equal("300000000"))
+		Expect(funded.Boosts).NotTo(BeEmpty())
+		milestone := funded.Boosts[0]
+		Expect(milestone.Booster).To(Equal("FundingMilestone"))
+		Expect(milestone.Fired).To(BeTrue())
+		Expect(milestone.Coef).To(Equal(300))
+		Expect(milestone.Inputs["minter_value"]).To(Equal("50000"))
+	})
+
+	It("should not trace scores outside of explanations", func() {
+		scm := leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(50000))
+		_, _ = leaderboard.NewPersonnalRanking(holder, buys()).Explain(scm)
+		_, trace := leaderboard.NewPersonnalRanking(holder, buys()[:1]).Explain(scm)
+		Expect(trace.Events).To(HaveLen(1))
+	})
+})
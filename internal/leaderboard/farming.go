@@ -0,0 +1,75 @@
+package leaderboard
+
+import (
+	"github.com/charmbracelet/log"
+	u256 "github.com/holiman/uint256"
+)
+
+const defaultFarmingPercent uint64 = 10
+
+func farmingPercent(multiplier *uint64) uint64 {
+	return settingOr(multiplier, defaultFarmingPercent)
+}
+
+// Farming deposit - Calculate points based on project value deposited in yielder or offseter
+type FarmingDepositScoreCalculator struct {
+	// Percent of deposited value given as points, defaults to 10
+	Multiplier *uint64
+}
+
+func (sc *FarmingDepositScoreCalculator) Supports(e DomainEvent, score []Score) bool {
+	return e.EventName == "yielder:deposit" || e.EventName == "offseter:deposit"
+}
+
+func (sc *FarmingDepositScoreCalculator) Compute(e DomainEvent, score []Score) *Score {
+	// e.Data["value"] equals project value * 10^6
+	value, err := u256.FromHex(e.Data["value"])
+	if err != nil {
+		log.Error("farming deposit - failed to parse value from hex", "event", e.EventName, "error", err)
+		return nil
+	}
+
+	return &Score{Points: mulCoeficient(value, u256.NewInt(farmingPercent(sc.Multiplier))), Event: e, Rule: FarmingDepositRuleName}
+}
+
+// Farming withdraw - Take back deposit points of withdrawn value.
+// Penalty is capped to deposit points earned on the same contract so that it never exceeds them.
+type FarmingWithdrawScoreCalculator struct {
+	// Percent of withdrawn value removed from points, defaults to 10
+	Multiplier *uint64
+}
+
+func (sc *FarmingWithdrawScoreCalculator) Supports(e DomainEvent, score []Score) bool {
+	return e.EventName == "yielder:withdraw" || e.EventName == "offseter:withdraw"
+}
+
+func (sc *FarmingWithdrawScoreCalculator) Compute(e DomainEvent, score []Score) *Score {
+	value, err := u256.FromHex(e.Data["value"])
+	if err != nil {
+		log.Error("farming withdraw - failed to parse value from hex", "event", e.EventName, "error", err)
+		return nil
+	}
+
+	remaining := u256.NewInt(0)
+	for _, s := range score {
+		if s.Event.FromAddress != e.FromAddress {
+			continue
+		}
+		switch s.Rule {
+		case FarmingDepositRuleName:
+			remaining.Add(remaining, s.Points)
+		case FarmingWithdrawRuleName:
+			remaining.Sub(remaining, s.Points)
+		}
+	}
+
+	points := mulCoeficient(value, u256.NewInt(farmingPercent(sc.Multiplier)))
+	if points.Cmp(remaining) > 0 {
+		points = remaining
+	}
+	if points.IsZero() {
+		return nil
+	}
+
+	return &Score{Points: points, Negative: true, Event: e, Rule: FarmingWithdrawRuleName}
+}
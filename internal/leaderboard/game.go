@@ -2,6 +2,7 @@ package leaderboard
 
 import (
 	"sort"
+	"time"
 
 	u256 "github.com/holiman/uint256"
 )
@@ -30,6 +31,13 @@ const (
 	EarlyAdopterRuleName     RuleName = "early_adopter"
 	OffseterRuleName         RuleName = "offseter"
 	ResalerRuleName          RuleName = "resaler"
+	HoldingRuleName          RuleName = "holding"
+	TransferClawbackRuleName RuleName = "transfer_clawback"
+	FarmingDepositRuleName   RuleName = "farming_deposit"
+	FarmingWithdrawRuleName  RuleName = "farming_withdraw"
+	StakingRuleName          RuleName = "staking"
+	ReferralRuleName         RuleName = "referral"
+	GrantRuleName            RuleName = "grant"
 	BoostRuleName            RuleName = "boost"
 
 	FundCategory    string = "fund"
@@ -39,16 +47,31 @@ const (
 
 type PersonnalRanking struct {
 	CustomerWallet string
-	Events         []DomainEvent
-	HandledEvents  []DomainEvent
+	// Other wallets of the same identity, their events are part of Events
+	LinkedWallets []string
+	Events        []DomainEvent
+	HandledEvents []DomainEvent
+	// Events replayed by history builders, defaults to Events
+	HistoryEvents []DomainEvent
+	// Period history builders award points for, Until defaults to now
+	From  time.Time
+	Until time.Time
 }
 
 type ScoreCalculatorBuilderFn func() ScoreCalculator
 
 type Score struct {
 	Points *u256.Int
-	Rule   RuleName
-	Boosts []Boost
+	// Points before boosts were applied
+	Base     *u256.Int
+	Rule     RuleName
+	Boosts   []Boost
+	Stacking StackingPolicy
+	Capped   bool
+	// Points are removed from total instead of being added
+	Negative bool
+	// Wallet of identity points were earned with
+	Wallet string
 	Event  DomainEvent
 }
 
@@ -58,11 +81,62 @@ func (pr *PersonnalRanking) ComputeScore(scm *ScoreCalculatorManager) *Leaderboa
 		scores = scm.ComputeScore(e, scores)
 		pr.HandledEvents = append(pr.HandledEvents, e)
 	}
+	for _, w := range pr.wallets() {
+		scores = scm.ComputeHistory(w, pr.historyEvents(), pr.From, pr.until(), scores)
+	}
 
 	totalScore := TotalScore(scores)
 	categories := AggregateCategories(scores)
+	if scm.trace != nil {
+		scm.trace.total(scores)
+	}
+
+	line := LeaderboardLineFromScore(pr.CustomerWallet, scores, *totalScore, categories)
+	line.RulesVersion = scm.Version()
+	line.FirstActivityAt = pr.firstActivityAt()
+	return line
+}
+
+// Events are sorted on creation, nil for wallets without any own event
+func (pr *PersonnalRanking) firstActivityAt() *time.Time {
+	if len(pr.Events) == 0 {
+		return nil
+	}
+	at := pr.Events[0].RecordedAt
+	return &at
+}
+
+// Use full history of wallet (including events stored for other wallets) for history builders
+func (pr *PersonnalRanking) WithHistory(events []DomainEvent, from time.Time, until time.Time) *PersonnalRanking {
+	sort.Sort(ByRecordedAtAndEventName(events))
+	pr.HistoryEvents = events
+	pr.From = from
+	pr.Until = until
+	return pr
+}
+
+// Rank wallets along with customer wallet as one identity
+func (pr *PersonnalRanking) WithLinkedWallets(wallets ...string) *PersonnalRanking {
+	pr.LinkedWallets = wallets
+	return pr
+}
+
+func (pr *PersonnalRanking) wallets() []string {
+	return append([]string{pr.CustomerWallet}, pr.LinkedWallets...)
+}
 
-	return LeaderboardLineFromScore(pr.CustomerWallet, scores, *totalScore, categories)
+func (pr *PersonnalRanking) historyEvents() []DomainEvent {
+	if pr.HistoryEvents == nil {
+		return pr.Events
+	}
+	return pr.HistoryEvents
+}
+
+func (pr *PersonnalRanking) until() time.Time {
+	if pr.Until.IsZero() {
+		return time.Now()
+	}
+	return pr.Until
 }
 
 func NewPersonnalRanking(wallet string, events []DomainEvent) *PersonnalRanking {
@@ -0,0 +1,181 @@
+package leaderboard
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// Events coming from indexed starknet contracts
+	EventSourceChain string = "chain"
+	// Events created by operators through admin api
+	EventSourceAdmin string = "admin"
+
+	GrantEventName string = "grant:points"
+)
+
+var (
+	ErrGrantNotFound       = errors.New("grant not found")
+	ErrGrantAlreadyRevoked = errors.New("grant already revoked")
+	ErrInvalidGrant        = errors.New("grant needs a wallet, non zero points, a reason and an operator")
+	ErrGrantExpiryInPast   = errors.New("grant expiry must be in the future")
+)
+
+// Points granted off-chain to a wallet (quest completion, community event, correction).
+// Grants are stored as domain events so that they are scored like any other event.
+type Grant struct {
+	ID            string `json:"id"`
+	WalletAddress string `json:"wallet_address"`
+	// Whole points, negative for corrections
+	Points    int64      `json:"points"`
+	Reason    string     `json:"reason"`
+	Operator  string     `json:"operator"`
+	GrantedAt time.Time  `json:"granted_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	RevokedBy string     `json:"revoked_by,omitempty"`
+}
+
+func GrantFromEvent(e DomainEvent) Grant {
+	points, _ := strconv.ParseInt(e.Data["points"], 10, 64)
+	return Grant{
+		ID:            e.EventId,
+		WalletAddress: e.WalletAddress,
+		Points:        points,
+		Reason:        e.Data["reason"],
+		Operator:      e.Data["operator"],
+		GrantedAt:     e.RecordedAt,
+		ExpiresAt:     parseUnixTime(e.Data["expires_at"]),
+		RevokedAt:     parseUnixTime(e.Data["revoked_at"]),
+		RevokedBy:     e.Data["revoked_by"],
+	}
+}
+
+func parseUnixTime(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.Unix(ts, 0)
+	return &t
+}
+
+func CreateGrant(db *gorm.DB, wallet string, points int64, reason string, operator string, expiresAt *time.Time) (*Grant, error) {
+	if wallet == "" || points == 0 || reason == "" || operator == "" {
+		return nil, ErrInvalidGrant
+	}
+	wallet = starknet.NormalizeAddress(wallet).String()
+	now := time.Now()
+	data := EventData{"points": strconv.FormatInt(points, 10), "reason": reason, "operator": operator}
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, ErrGrantExpiryInPast
+		}
+		data["expires_at"] = strconv.FormatInt(expiresAt.Unix(), 10)
+	}
+
+	id := ulid.Make()
+	e := DomainEvent{
+		RecordedAt:    now,
+		Data:          data,
+		Metadata:      EventMetadata{},
+		EventId:       "grant#" + id.String(),
+		EventName:     GrantEventName,
+		WalletAddress: wallet,
+		Keys:          EventKeys{},
+		Source:        EventSourceAdmin,
+		ID:            id,
+	}
+	if err := db.Create(&e).Error; err != nil {
+		return nil, err
+	}
+	g := GrantFromEvent(e)
+	return &g, nil
+}
+
+// Revoked grants are kept with who revoked them and when so that history stays auditable
+func RevokeGrant(db *gorm.DB, id string, operator string) (*Grant, error) {
+	var e DomainEvent
+	err := db.Where("event_id = ? AND event_name = ?", id, GrantEventName).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGrantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Data["revoked_at"] != "" {
+		return nil, ErrGrantAlreadyRevoked
+	}
+
+	e.Data["revoked_at"] = strconv.FormatInt(time.Now().Unix(), 10)
+	e.Data["revoked_by"] = operator
+	if err := db.Model(&e).Update("data", e.Data).Error; err != nil {
+		return nil, err
+	}
+	g := GrantFromEvent(e)
+	return &g, nil
+}
+
+// Grants of wallet, every grant when wallet is empty
+func GetGrants(db *gorm.DB, wallet string) ([]Grant, error) {
+	q := db.Where("event_name = ?", GrantEventName)
+	if wallet != "" {
+		q = q.Where("wallet_address = ?", wallet)
+	}
+	var events []DomainEvent
+	if err := q.Order("recorded_at DESC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	grants := []Grant{}
+	for _, e := range events {
+		grants = append(grants, GrantFromEvent(e))
+	}
+	return grants, nil
+}
+
+// Grant - Points granted by operators. Revoked and expired grants are not scored
+type GrantScoreCalculator struct {
+	// Time expiry is checked against, defaults to time.Now
+	Now func() time.Time
+}
+
+func (sc *GrantScoreCalculator) now() time.Time {
+	if sc.Now == nil {
+		return time.Now()
+	}
+	return sc.Now()
+}
+
+func (sc *GrantScoreCalculator) Supports(e DomainEvent, score []Score) bool {
+	if e.EventName != GrantEventName {
+		return false
+	}
+	g := GrantFromEvent(e)
+	return g.RevokedAt == nil && (g.ExpiresAt == nil || sc.now().Before(*g.ExpiresAt))
+}
+
+func (sc *GrantScoreCalculator) Compute(e DomainEvent, score []Score) *Score {
+	g := GrantFromEvent(e)
+	if g.Points == 0 {
+		return nil
+	}
+
+	points := g.Points
+	if points < 0 {
+		points = -points
+	}
+	// NOTE: points are stored multiplied by 10^6 like on-chain values
+	var p u256.Int
+	p.Mul(u256.NewInt(uint64(points)), u256.NewInt(1000000))
+	return &Score{Points: &p, Negative: g.Points < 0, Event: e, Rule: GrantRuleName}
+}
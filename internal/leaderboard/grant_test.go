@@ -0,0 +1,54 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func grantEvt(id string, data map[string]string) leaderboard.DomainEvent {
+	data["reason"] = "quest"
+	data["operator"] = "alice"
+	return newEvent(id, leaderboard.GrantEventName, data, map[string]string{}, []string{}, t0)
+}
+
+var _ = Describe("GrantScoreCalculator", func() {
+	scm := leaderboard.NewScoreCalculatorManager(
+		leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}, &leaderboard.GrantScoreCalculator{Now: func() time.Time { return time.Unix(t0+day, 0) }}),
+	)
+	// 1000$
+	buy := newEvent("buy", "minter:buy", map[string]string{"address": holder, "value": "0x3b9aca00"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, []string{}, t0)
+
+	It("should score grants in other category", func() {
+		line := leaderboard.NewPersonnalRanking(holder, []leaderboard.DomainEvent{buy, grantEvt("grant#1", map[string]string{"points": "50"})}).ComputeScore(scm)
+		Expect(line.TotalScore).To(Equal("1050"))
+		Expect(line.Categories.Other).To(Equal("50"))
+		Expect(line.Categories.Fund).To(Equal("1000"))
+	})
+
+	It("should remove points of negative grants", func() {
+		line := leaderboard.NewPersonnalRanking(holder, []leaderboard.DomainEvent{buy, grantEvt("grant#1", map[string]string{"points": "-200"})}).ComputeScore(scm)
+		Expect(line.TotalScore).To(Equal("800"))
+	})
+
+	It("should ignore revoked and expired grants", func() {
+		events := []leaderboard.DomainEvent{
+			buy,
+			grantEvt("grant#1", map[string]string{"points": "50", "revoked_at": "1700000000", "revoked_by": "bob"}),
+			grantEvt("grant#2", map[string]string{"points": "50", "expires_at": "1"}),
+		}
+		line := leaderboard.NewPersonnalRanking(holder, events).ComputeScore(scm)
+		Expect(line.TotalScore).To(Equal("1000"))
+	})
+
+	It("should expose audit trail of grant", func() {
+		g := leaderboard.GrantFromEvent(grantEvt("grant#1", map[string]string{"points": "-5", "revoked_at": "1700000000", "revoked_by": "bob"}))
+		Expect(g.Points).To(Equal(int64(-5)))
+		Expect(g.Operator).To(Equal("alice"))
+		Expect(g.RevokedBy).To(Equal("bob"))
+		Expect(g.RevokedAt.Unix()).To(Equal(int64(1700000000)))
+		Expect(g.ExpiresAt).To(BeNil())
+	})
+})
@@ -0,0 +1,253 @@
+package leaderboard
+
+import (
+	"sort"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+)
+
+// Builds scores from the whole history of a wallet instead of one event at a time.
+// Points are awarded for what happened between from and until.
+type HistoryScoreBuilder interface {
+	ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time) []Score
+}
+
+// History builder relying on scores computed by event builders, e.g. to take back points granted on funding
+type ScoredHistoryScoreBuilder interface {
+	HistoryScoreBuilder
+	ComputeScoredHistory(wallet string, events []DomainEvent, from time.Time, until time.Time, score []Score) []Score
+}
+
+const (
+	defaultHoldingPoints uint64 = 1
+	secondsPerDay        uint64 = 86400
+)
+
+// Holding - Calculate points based on project value held over time
+type HoldingScoreCalculator struct {
+	// Points per 1000$ held for a day, defaults to 1
+	Points *uint64
+}
+
+type heldToken struct {
+	value *u256.Int
+	// part of value minted to wallet, received value is not funded by wallet
+	funded  *u256.Int
+	project string
+	slot    string
+	// last known owner, empty when token was never transferred in wallet history
+	owner string
+	owned bool
+}
+
+type heldProject struct {
+	// sum of value * seconds held
+	valueSeconds *u256.Int
+	project      string
+	slot         string
+}
+
+// Project tokens of wallet and their value, rebuilt from project events
+type tokenLedger struct {
+	tokens map[string]*heldToken
+	wallet string
+}
+
+func newTokenLedger(wallet string) *tokenLedger {
+	return &tokenLedger{tokens: make(map[string]*heldToken), wallet: wallet}
+}
+
+func (sc *HoldingScoreCalculator) pointsPerThousandDollarDay() uint64 {
+	return settingOr(sc.Points, defaultHoldingPoints)
+}
+
+// Replays project token transfers to know which tokens were owned by wallet and their value over time.
+// Events must be sorted and can contain events stored for other wallets (outgoing transfers, value sent from owned tokens).
+func (sc *HoldingScoreCalculator) ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time) []Score {
+	ledger := newTokenLedger(wallet)
+	held := make(map[string]*heldProject)
+	last := from
+
+	accrue := func(t time.Time) {
+		if t.After(until) {
+			t = until
+		}
+		if !t.After(last) {
+			return
+		}
+		seconds := u256.NewInt(uint64(t.Sub(last).Seconds()))
+		for _, tok := range ledger.tokens {
+			if !tok.owned || tok.value.IsZero() || tok.project == "" {
+				continue
+			}
+			hp, exists := held[tok.project]
+			if !exists {
+				hp = &heldProject{valueSeconds: u256.NewInt(0), project: tok.project, slot: tok.slot}
+				held[tok.project] = hp
+			}
+			var vs u256.Int
+			vs.Mul(tok.value, seconds)
+			hp.valueSeconds.Add(hp.valueSeconds, &vs)
+		}
+		last = t
+	}
+
+	for _, e := range events {
+		if e.RecordedAt.After(until) {
+			break
+		}
+		accrue(e.RecordedAt)
+		ledger.apply(e)
+	}
+	accrue(until)
+
+	var projects []string
+	for p := range held {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+
+	var scores []Score
+	for _, p := range projects {
+		hp := held[p]
+		// NOTE: value is already multiplied by 10^6
+		var points u256.Int
+		points.Mul(hp.valueSeconds, u256.NewInt(sc.pointsPerThousandDollarDay()))
+		points.Div(&points, u256.NewInt(secondsPerDay*1000))
+		if points.IsZero() {
+			continue
+		}
+		scores = append(scores, Score{
+			Points: &points,
+			Rule:   HoldingRuleName,
+			Event: DomainEvent{
+				RecordedAt:    until,
+				EventName:     "project:holding",
+				WalletAddress: wallet,
+				Metadata:      EventMetadata{"project_name": hp.project, "slot": hp.slot},
+			},
+		})
+	}
+	return scores
+}
+
+// Project value held by a wallet
+type ProjectHolding struct {
+	Project string
+	Slot    string
+	Value   *u256.Int
+}
+
+// Replays project token transfers to know what wallet holds by project and slot once every event happened, events must be sorted
+func CurrentHoldings(wallet string, events []DomainEvent) []ProjectHolding {
+	ledger := newTokenLedger(wallet)
+	for _, e := range events {
+		ledger.apply(e)
+	}
+
+	held := make(map[string]*ProjectHolding)
+	for _, tok := range ledger.tokens {
+		if !tok.owned || tok.value.IsZero() || tok.project == "" {
+			continue
+		}
+		// NOTE: a project can have several slots, each of them is a distinct holding
+		key := tok.project + "#" + tok.slot
+		h, exists := held[key]
+		if !exists {
+			h = &ProjectHolding{Project: tok.project, Slot: tok.slot, Value: u256.NewInt(0)}
+			held[key] = h
+		}
+		h.Value.Add(h.Value, tok.value)
+	}
+
+	var holdings []ProjectHolding
+	for _, h := range held {
+		holdings = append(holdings, *h)
+	}
+	sort.Slice(holdings, func(i, j int) bool {
+		if holdings[i].Project != holdings[j].Project {
+			return holdings[i].Project < holdings[j].Project
+		}
+		return holdings[i].Slot < holdings[j].Slot
+	})
+	return holdings
+}
+
+func (l *tokenLedger) apply(e DomainEvent) {
+	switch e.EventName {
+	case "project:transfer":
+		tok := l.token(e, e.Data["token_id"])
+		tok.owner = e.Data["to"]
+		if starknet.SameAddress(e.Data["to"], l.wallet) {
+			tok.owned = true
+		} else if starknet.SameAddress(e.Data["from"], l.wallet) {
+			tok.owned = false
+			tok.funded.Clear()
+		}
+	case "project:transfer-value":
+		value, err := u256.FromHex(e.Data["value"])
+		if err != nil {
+			return
+		}
+		to := l.token(e, e.Data["to_token_id"])
+		fromId := e.Data["from_token_id"]
+		if starknet.HexStringToUint64(fromId) == 0 {
+			// minted value
+			if to.owned {
+				to.funded.Add(to.funded, value)
+			}
+			to.value.Add(to.value, value)
+			return
+		}
+		from := l.token(e, fromId)
+		funded := from.fundedShare(value)
+		from.funded.Sub(from.funded, funded)
+		if to.owned {
+			to.funded.Add(to.funded, funded)
+		}
+		to.value.Add(to.value, value)
+		if from.value.Cmp(value) <= 0 {
+			from.value.Clear()
+		} else {
+			from.value.Sub(from.value, value)
+		}
+	case "project:slot-changed":
+		tok := l.token(e, e.Data["token_id"])
+		tok.project = e.Metadata["project_name"]
+		tok.slot = e.Metadata["slot"]
+	}
+}
+
+// Tokens are identified by project contract and token id
+func (l *tokenLedger) token(e DomainEvent, tokenId string) *heldToken {
+	key := normalizeFelt(e.FromAddress) + "#" + normalizeFelt(tokenId)
+	tok, exists := l.tokens[key]
+	if !exists {
+		tok = &heldToken{value: u256.NewInt(0), funded: u256.NewInt(0)}
+		l.tokens[key] = tok
+	}
+	if tok.project == "" {
+		tok.project = e.Metadata["project_name"]
+		tok.slot = e.Metadata["slot"]
+	}
+	return tok
+}
+
+// Funded part of value moved out of token, in proportion of funded value of the token
+func (t *heldToken) fundedShare(value *u256.Int) *u256.Int {
+	share := u256.NewInt(0)
+	if t.value.IsZero() || t.funded.IsZero() {
+		return share
+	}
+	if value.Cmp(t.value) >= 0 {
+		return share.Set(t.funded)
+	}
+	share.Mul(t.funded, value)
+	return share.Div(share, t.value)
+}
+
+func normalizeFelt(s string) string {
+	return starknet.NormalizeAddress(s).String()
+}
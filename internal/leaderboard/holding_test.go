@@ -0,0 +1,78 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	holder = "0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2"
+	buyer  = "0x2"
+	day    = int64(86400)
+	t0     = int64(1703845777)
+)
+
+func mintTokenEvts(tokenId string, value string, ts int64) []leaderboard.DomainEvent {
+	metadata := map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}
+	return []leaderboard.DomainEvent{
+		newProjectTransferEvt("transfer_"+tokenId, map[string]string{"from": "0x0", "to": holder, "token_id": tokenId}, metadata, []string{}, ts),
+		newProjectTransferValueEvt("transfer_value_"+tokenId, map[string]string{"from_token_id": "0x0", "to_token_id": tokenId, "value": value}, metadata, ts),
+	}
+}
+
+func transferTokenEvt(tokenId string, to string, ts int64) leaderboard.DomainEvent {
+	evt := newProjectTransferEvt("transfer_out_"+tokenId, map[string]string{"from": holder, "to": to, "token_id": tokenId}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, []string{}, ts)
+	evt.WalletAddress = to
+	return evt
+}
+
+func holdingScore(events []leaderboard.DomainEvent, from int64, until int64) string {
+	scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithHistoryBuilders(&leaderboard.HoldingScoreCalculator{Points: setting(1)}))
+	pr := leaderboard.NewPersonnalRanking(holder, events).WithHistory(events, time.Unix(from, 0), time.Unix(until, 0))
+	return pr.ComputeScore(scm).TotalScore
+}
+
+var _ = Describe("HoldingScoreCalculator", func() {
+	// 1000$
+	value := "0x3b9aca00"
+
+	It("should award points for value held over time", func() {
+		events := mintTokenEvts("0x1", value, t0)
+		Expect(holdingScore(events, 0, t0+10*day)).To(Equal("10"))
+	})
+
+	It("should stop accruing once token left wallet", func() {
+		events := append(mintTokenEvts("0x1", value, t0), transferTokenEvt("0x1", buyer, t0+5*day))
+		Expect(holdingScore(events, 0, t0+10*day)).To(Equal("5"))
+	})
+
+	It("should rank long-term holders above flippers", func() {
+		flipper := append(mintTokenEvts("0x1", value, t0), transferTokenEvt("0x1", buyer, t0+day))
+		Expect(holdingScore(flipper, 0, t0+10*day)).To(Equal("1"))
+		Expect(holdingScore(mintTokenEvts("0x1", value, t0), 0, t0+10*day)).To(Equal("10"))
+	})
+
+	It("should follow value moved out of owned token", func() {
+		events := mintTokenEvts("0x1", value, t0)
+		// half of the value is sent to another token at day 2
+		events = append(events, newProjectTransferValueEvt("split", map[string]string{"from_token_id": "0x1", "to_token_id": "0x2", "value": "0x1dcd6500"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, t0+2*day))
+		Expect(holdingScore(events, 0, t0+10*day)).To(Equal("6"))
+	})
+
+	It("should only award points inside period", func() {
+		events := mintTokenEvts("0x1", value, t0)
+		Expect(holdingScore(events, t0+2*day, t0+5*day)).To(Equal("3"))
+	})
+
+	It("should be categorised as fund", func() {
+		scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithHistoryBuilders(&leaderboard.HoldingScoreCalculator{Points: setting(1)}))
+		events := mintTokenEvts("0x1", value, t0)
+		line := leaderboard.NewPersonnalRanking(holder, events).WithHistory(events, time.Time{}, time.Unix(t0+10*day, 0)).ComputeScore(scm)
+		Expect(line.Categories.Fund).To(Equal("10"))
+		Expect(line.Points[0].Rule).To(Equal(string(leaderboard.HoldingRuleName)))
+		Expect(line.Points[0].Metadata["project_name"]).To(Equal("Banegas Farm"))
+	})
+})
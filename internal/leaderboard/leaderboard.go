@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"math"
 	"time"
 
 	"github.com/carbonable/leaderboard/internal/starknet"
 	u256 "github.com/holiman/uint256"
 	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
 )
 
 // NOTE: Types to ease use of json in gorm and postgres
@@ -37,36 +38,95 @@ type DomainEvent struct {
 	FromAddress   string
 	WalletAddress string
 	Keys          EventKeys `gorm:"serializer:json;type:jsonb"`
-	ID            ulid.ULID `gorm:"primaryKey"`
+	// Where event comes from, chain or admin
+	Source string `gorm:"default:chain"`
+	// Block event was emitted in, 0 for events that do not come from chain
+	BlockNumber uint64    `gorm:"index"`
+	ID          ulid.ULID `gorm:"primaryKey"`
+}
+
+// Data keys holding wallet addresses, stored in canonical form like WalletAddress
+var AddressDataKeys = []string{"address", "from", "to", "referee", "referrer"}
+
+func normalizeEventAddresses(data map[string]string) map[string]string {
+	for _, k := range AddressDataKeys {
+		if v, exists := data[k]; exists {
+			data[k] = starknet.NormalizeAddress(v).String()
+		}
+	}
+	return data
 }
 
 func DomainEventFromStarknetEvent(event *starknet.Event, eventName string, wallet string, data map[string]string, metadata map[string]string) *DomainEvent {
 	return &DomainEvent{
 		RecordedAt:    event.RecordedAt,
 		EventId:       event.EventId,
+		BlockNumber:   event.BlockNumber,
 		EventNameFelt: event.Keys[0],
 		EventName:     eventName,
-		FromAddress:   event.FromAddress,
-		WalletAddress: wallet,
+		FromAddress:   starknet.NormalizeAddress(event.FromAddress).String(),
+		WalletAddress: starknet.NormalizeAddress(wallet).String(),
 		Keys:          event.Keys[1:],
-		Data:          data,
+		Data:          normalizeEventAddresses(data),
 		Metadata:      metadata,
+		Source:        EventSourceChain,
 		ID:            ulid.Make(),
 	}
 }
 
+// Set block number of chain events stored before it was recorded. Event ids of a contract,
+// indexed by block number, are given by the indexer storage
+func BackfillEventBlockNumbers(db *gorm.DB, contractEventIds func(contract string) (map[uint64][]string, error)) error {
+	var contracts []string
+	if err := db.Model(&DomainEvent{}).Where("source = ? AND block_number = 0", EventSourceChain).Distinct().Pluck("from_address", &contracts).Error; err != nil {
+		return err
+	}
+	for _, contract := range contracts {
+		ids, err := contractEventIds(contract)
+		if err != nil {
+			return err
+		}
+		for block, eventIds := range ids {
+			if err := db.Model(&DomainEvent{}).Where("event_id IN ? AND block_number = 0", eventIds).Update("block_number", block).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NOTE: wallet is only indexed as season lines embed LeaderboardLine,
+// uniqueness on all-time lines is enforced by EnsureUniqueLineWallets
 type LeaderboardLine struct {
 	Categories    CategorisedScore `gorm:"serializer:json;type:jsonb"`
-	WalletAddress string           `gorm:"unique"`
+	WalletAddress string           `gorm:"index"`
 	TotalScore    string
-	Points        Points    `gorm:"serializer:json;type:jsonb"`
-	ID            ulid.ULID `gorm:"primaryKey"`
+	RulesVersion  string
+	Points        Points `gorm:"serializer:json;type:jsonb"`
+	// Score of each wallet of the identity
+	Wallets WalletScores `gorm:"serializer:json;type:jsonb"`
+	// Numeric total score lines are ranked by
+	Score int64 `gorm:"index"`
+	// Position computed by aggregator, 0 for excluded wallets
+	Rank int `gorm:"index"`
+	// Earliest event of wallet, first active wallet ranks first on equal scores
+	FirstActivityAt *time.Time
+	ID              ulid.ULID `gorm:"primaryKey"`
 }
 
+type WalletScore struct {
+	WalletAddress string `json:"wallet_address"`
+	TotalScore    string `json:"total_score"`
+}
+
+type WalletScores []WalletScore
+
 type Point struct {
-	Metadata EventMetadata `json:"metadata" gorm:"serializer:json;type:jsonb"`
-	Rule     string        `json:"rule"`
-	Value    uint          `json:"value"`
+	Metadata  EventMetadata   `json:"metadata" gorm:"serializer:json;type:jsonb"`
+	Breakdown *PointBreakdown `json:"breakdown,omitempty"`
+	Rule      string          `json:"rule"`
+	// Negative when points are removed from total
+	Value int `json:"value"`
 }
 
 type CategorisedScore struct {
@@ -82,18 +142,6 @@ type MinterBuyValue struct {
 	Value u256.Int  `gorm:"type:numeric"`
 }
 
-func boostsToString(s *Score, metadata EventMetadata) EventMetadata {
-	// FIX: clear event metadata state
-	if len(s.Boosts) > 0 {
-		var boosts []string
-		for _, b := range s.Boosts {
-			boosts = append(boosts, fmt.Sprintf("x%.1f - %s", float32(b.Value)/100, b.DisplayName))
-		}
-		metadata["boosts"] = strings.Join(boosts, " // ")
-	}
-	return metadata
-}
-
 func buildPointMetadata(s *Score) EventMetadata {
 	metadata := make(EventMetadata)
 	metadata["project_name"] = s.Event.Metadata["project_name"]
@@ -101,12 +149,47 @@ func buildPointMetadata(s *Score) EventMetadata {
 	// NOTE: convert to js usable timestamp
 	metadata["date"] = fmt.Sprintf("%d", s.Event.RecordedAt.Unix()*1000)
 	metadata["event"] = s.Event.EventName
+	metadata["event_id"] = s.Event.EventId
 	metadata["rule"] = string(s.Rule)
-	metadata = boostsToString(s, metadata)
+	metadata["wallet"] = s.Wallet
+	// NOTE: kept for clients reading boosts from metadata, breakdown holds the details
+	if len(s.Boosts) > 0 {
+		metadata["boosts"] = BoostsDisplay(s.Boosts)
+	}
 
 	return metadata
 }
 
+func signedPoints(points *u256.Int, negative bool) int {
+	value := int(points.Uint64())
+	if negative {
+		return -value
+	}
+	return value
+}
+
+// Split scores by wallet they were earned with, line wallet comes first
+func walletScores(wallet string, score []Score) WalletScores {
+	order := []string{wallet}
+	byWallet := map[string][]Score{wallet: {}}
+	for _, s := range score {
+		w := s.Wallet
+		if w == "" {
+			w = wallet
+		}
+		if _, exists := byWallet[w]; !exists {
+			order = append(order, w)
+		}
+		byWallet[w] = append(byWallet[w], s)
+	}
+
+	var res WalletScores
+	for _, w := range order {
+		res = append(res, WalletScore{WalletAddress: w, TotalScore: TotalScore(byWallet[w]).String()})
+	}
+	return res
+}
+
 func LeaderboardLineFromScore(wallet string, score []Score, totalScore u256.Int, categories *CategorisedScore) *LeaderboardLine {
 	var points Points
 	for _, s := range score {
@@ -116,17 +199,27 @@ func LeaderboardLineFromScore(wallet string, score []Score, totalScore u256.Int,
 		}
 		metadata := buildPointMetadata(&s)
 
-		points = append(points, Point{Metadata: metadata, Rule: string(s.Rule), Value: uint(s.Points.Uint64())})
+		points = append(points, Point{Metadata: metadata, Breakdown: PointBreakdownFromScore(&s), Rule: string(s.Rule), Value: signedPoints(s.Points, s.Negative)})
 	}
 	return &LeaderboardLine{
 		WalletAddress: wallet,
 		Points:        points,
+		Wallets:       walletScores(wallet, score),
 		ID:            ulid.Make(),
 		TotalScore:    totalScore.String(),
+		Score:         scoreValue(&totalScore),
 		Categories:    *categories,
 	}
 }
 
+// Saturates at max int64 so that ranking column never overflows
+func scoreValue(total *u256.Int) int64 {
+	if !total.IsUint64() || total.Uint64() > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(total.Uint64())
+}
+
 // EventData
 func (a EventData) Value() (driver.Value, error) {
 	return json.Marshal(a)
@@ -183,6 +276,20 @@ func (a *Points) Scan(value interface{}) error {
 	return json.Unmarshal(b, &a)
 }
 
+// WalletScores
+func (a WalletScores) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *WalletScores) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, &a)
+}
+
 // CategorisedScore
 func (a CategorisedScore) Value() (driver.Value, error) {
 	return json.Marshal(a)
@@ -46,3 +46,23 @@ func TestBuildPointMetadata(t *testing.T) {
 	assert.Equal(metadata["boosts"], "x2.0 - Funding Karathuru // x1.5 - Funding project", "event name should match")
 	assert.Equal(metadata["rule"], "therule", "event rule should match")
 }
+
+func TestPointBreakdownFromScore(t *testing.T) {
+	assert := assert.New(t)
+	s := &Score{
+		Points:   uint256.NewInt(300),
+		Base:     uint256.NewInt(100),
+		Rule:     "therule",
+		Stacking: MultiplicativeStacking,
+		Boosts: []Boost{
+			{Name: "Karathuru", DisplayName: "Funding Karathuru", Value: 200},
+			{Name: "Project funding", DisplayName: "Funding project", Value: 150},
+		},
+	}
+
+	b := PointBreakdownFromScore(s)
+	assert.Equal(100, b.Base, "base should match")
+	assert.Equal(300, b.Final, "final should match")
+	assert.Equal(MultiplicativeStacking, b.Stacking, "stacking should match")
+	assert.Equal("x2.0 - Funding Karathuru // x1.5 - Funding project", BoostsDisplay(b.Boosts), "boosts display should match")
+}
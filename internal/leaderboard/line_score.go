@@ -0,0 +1,208 @@
+package leaderboard
+
+import (
+	"errors"
+	"sort"
+
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// Score of line in fund, farming or other category
+	CategoryDimension string = "category"
+	// Score of line from one rule
+	RuleDimension string = "rule"
+	// Score of line earned on one project
+	ProjectDimension string = "project"
+	// Value in $ of project currently held
+	HeldProjectDimension string = "held_project"
+	// Value in $ of project slot currently held, keyed by HeldSlotKey
+	HeldSlotDimension string = "held_slot"
+)
+
+var (
+	ErrInvalidRankingView = errors.New("ranking view needs a category, rule or project to sort by")
+	ErrSlotWithoutProject = errors.New("ranking view slot filter requires a project")
+)
+
+// Slot numbers are shared by every project, held slots are keyed by project and slot
+func HeldSlotKey(project string, slot string) string {
+	return project + "#" + slot
+}
+
+// Score of a line along one dimension. Written by aggregator along with line
+// so that rankings by category, rule or project never parse line points
+type LineScore struct {
+	LineID    ulid.ULID `gorm:"index:idx_line_scores_lookup,priority:1"`
+	Dimension string    `gorm:"index:idx_line_scores_lookup,priority:2"`
+	Key       string    `gorm:"index:idx_line_scores_lookup,priority:3"`
+	Score     int64
+	ID        ulid.ULID `gorm:"primaryKey"`
+}
+
+// Split line score by category, rule and project, and record current holdings of its wallets
+func LineScores(line *LeaderboardLine, holdings []ProjectHolding) []LineScore {
+	var scores []LineScore
+	add := func(dimension string, key string, score int64) {
+		if key == "" || score <= 0 {
+			return
+		}
+		scores = append(scores, LineScore{LineID: line.ID, Dimension: dimension, Key: key, Score: score, ID: ulid.Make()})
+	}
+
+	add(CategoryDimension, FundCategory, decimalScore(line.Categories.Fund))
+	add(CategoryDimension, FarmingCategory, decimalScore(line.Categories.Farming))
+	add(CategoryDimension, OtherCategory, decimalScore(line.Categories.Other))
+
+	rules, projects := make(map[string]int64), make(map[string]int64)
+	for _, p := range line.Points {
+		rules[p.Rule] += int64(p.Value)
+		projects[p.Metadata["project_name"]] += int64(p.Value)
+	}
+	for _, k := range sortedKeys(rules) {
+		add(RuleDimension, k, rules[k]/1000000)
+	}
+	for _, k := range sortedKeys(projects) {
+		add(ProjectDimension, k, projects[k]/1000000)
+	}
+
+	heldProjects, heldSlots := make(map[string]*u256.Int), make(map[string]*u256.Int)
+	for _, h := range holdings {
+		addHolding(heldProjects, h.Project, h.Value)
+		addHolding(heldSlots, HeldSlotKey(h.Project, h.Slot), h.Value)
+	}
+	for _, k := range sortedKeys(heldProjects) {
+		add(HeldProjectDimension, k, heldValue(heldProjects[k]))
+	}
+	for _, k := range sortedKeys(heldSlots) {
+		add(HeldSlotDimension, k, heldValue(heldSlots[k]))
+	}
+	return scores
+}
+
+func addHolding(held map[string]*u256.Int, key string, value *u256.Int) {
+	if _, exists := held[key]; !exists {
+		held[key] = u256.NewInt(0)
+	}
+	held[key].Add(held[key], value)
+}
+
+// NOTE: held values are multiplied by 10^6, a wallet holding less than 1$ still holds the project
+func heldValue(value *u256.Int) int64 {
+	var dollars u256.Int
+	dollars.Div(value, u256.NewInt(1000000))
+	if dollars.IsZero() && !value.IsZero() {
+		return 1
+	}
+	return scoreValue(&dollars)
+}
+
+func decimalScore(s string) int64 {
+	v, err := u256.FromDecimal(s)
+	if err != nil {
+		return 0
+	}
+	return scoreValue(v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Line scores of lines that were replaced by a newer aggregation
+func DeleteOrphanLineScores(db *gorm.DB) error {
+	return db.Exec("DELETE FROM line_scores WHERE line_id NOT IN (SELECT id FROM leaderboard_lines UNION ALL SELECT id FROM season_leaderboard_lines)").Error
+}
+
+// Fill line scores of lines computed before they existed. Holdings are left empty until next aggregator run
+func BackfillLineScores(db *gorm.DB) error {
+	for _, table := range []string{"leaderboard_lines", "season_leaderboard_lines"} {
+		var lines []LeaderboardLine
+		err := db.Table(table).Where("id NOT IN (SELECT line_id FROM line_scores)").FindInBatches(&lines, 100, func(tx *gorm.DB, batch int) error {
+			for i := range lines {
+				if scores := LineScores(&lines[i], nil); len(scores) > 0 {
+					if err := db.Create(&scores).Error; err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ranking sorted by one line score and restricted to holders of a project or slot.
+// Default view is the ranking by total score
+type RankingView struct {
+	// Dimension and key of line score to sort by, total score when empty
+	Dimension string
+	Key       string
+	// Only wallets currently holding project, or slot of project when slot is given
+	Project string
+	Slot    string
+}
+
+func (v RankingView) IsDefault() bool {
+	return v == RankingView{}
+}
+
+func (v RankingView) Validate() error {
+	if v.Slot != "" && v.Project == "" {
+		return ErrSlotWithoutProject
+	}
+	if v.Dimension == "" {
+		return nil
+	}
+	if v.Key == "" || (v.Dimension != CategoryDimension && v.Dimension != RuleDimension && v.Dimension != ProjectDimension) {
+		return ErrInvalidRankingView
+	}
+	return nil
+}
+
+// Lines of view with their position, ties keep the order of total ranking so that positions
+// are unique the same way ranks are, see rankLinesQuery
+func (v RankingView) query(db *gorm.DB, seasonID *ulid.ULID) *gorm.DB {
+	q := db.Table("leaderboard_lines l")
+	if seasonID != nil {
+		q = db.Table("season_leaderboard_lines l").Where("l.season_id = ?", *seasonID)
+	}
+	q = q.Where("l.rank > 0")
+
+	order := "l.rank ASC"
+	if v.Dimension != "" {
+		q = q.Joins("LEFT JOIN line_scores s ON s.line_id = l.id AND s.dimension = ? AND s.key = ?", v.Dimension, v.Key)
+		order = "COALESCE(s.score, 0) DESC, l.rank ASC"
+	}
+	// NOTE: held slot key carries the project, project and slot are matched by the same line score
+	if v.Slot != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM line_scores h WHERE h.line_id = l.id AND h.dimension = ? AND h.key = ?)", HeldSlotDimension, HeldSlotKey(v.Project, v.Slot))
+	} else if v.Project != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM line_scores h WHERE h.line_id = l.id AND h.dimension = ? AND h.key = ?)", HeldProjectDimension, v.Project)
+	}
+	return db.Table("(?) AS v", q.Select("l.*, ROW_NUMBER() OVER(ORDER BY "+order+") AS position"))
+}
+
+// Offset page of view along with the number of lines in view
+func GetRankingViewPage(db *gorm.DB, seasonID *ulid.ULID, view RankingView, offset int, limit int) ([]RankedLine, int64, error) {
+	if err := view.Validate(); err != nil {
+		return nil, 0, err
+	}
+	var count int64
+	if err := view.query(db, seasonID).Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	lines := []RankedLine{}
+	err := view.query(db, seasonID).Order("position ASC").Offset(offset).Limit(limit).Find(&lines).Error
+	return lines, count, err
+}
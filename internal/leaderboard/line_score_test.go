@@ -0,0 +1,125 @@
+package leaderboard_test
+
+import (
+	"strings"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	u256 "github.com/holiman/uint256"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func lineScore(scores []leaderboard.LineScore, dimension string, key string) int64 {
+	for _, s := range scores {
+		if s.Dimension == dimension && s.Key == key {
+			return s.Score
+		}
+	}
+	return -1
+}
+
+var _ = Describe("CurrentHoldings", func() {
+	// 1000$
+	value := "0x3b9aca00"
+
+	It("should sum value of owned tokens by project", func() {
+		events := append(mintTokenEvts("0x1", value, t0), mintTokenEvts("0x2", value, t0+day)...)
+		holdings := leaderboard.CurrentHoldings(holder, events)
+		Expect(holdings).To(HaveLen(1))
+		Expect(holdings[0].Project).To(Equal("Banegas Farm"))
+		Expect(holdings[0].Slot).To(Equal("0x1"))
+		Expect(holdings[0].Value.Uint64()).To(Equal(uint64(2000000000)))
+	})
+
+	It("should keep slots of the same project apart", func() {
+		metadata := map[string]string{"slot": "0x2", "project_name": "Banegas Farm"}
+		events := append(mintTokenEvts("0x1", value, t0),
+			newProjectTransferEvt("transfer_0x2", map[string]string{"from": "0x0", "to": holder, "token_id": "0x2"}, metadata, []string{}, t0+day),
+			newProjectTransferValueEvt("transfer_value_0x2", map[string]string{"from_token_id": "0x0", "to_token_id": "0x2", "value": "0x1dcd6500"}, metadata, t0+day),
+		)
+		holdings := leaderboard.CurrentHoldings(holder, events)
+		Expect(holdings).To(HaveLen(2))
+		Expect(holdings[0].Slot).To(Equal("0x1"))
+		Expect(holdings[0].Value.Uint64()).To(Equal(uint64(1000000000)))
+		Expect(holdings[1].Project).To(Equal("Banegas Farm"))
+		Expect(holdings[1].Slot).To(Equal("0x2"))
+		Expect(holdings[1].Value.Uint64()).To(Equal(uint64(500000000)))
+	})
+
+	It("should forget tokens sent to another wallet", func() {
+		events := append(mintTokenEvts("0x1", value, t0), transferTokenEvt("0x1", buyer, t0+day))
+		Expect(leaderboard.CurrentHoldings(holder, events)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("LineScores", func() {
+	line := &leaderboard.LeaderboardLine{
+		Categories: leaderboard.CategorisedScore{Fund: "300", Farming: "0", Other: "25"},
+		Points: leaderboard.Points{
+			{Rule: "amount_funded", Value: 200000000, Metadata: leaderboard.EventMetadata{"project_name": "Banegas Farm"}},
+			{Rule: "amount_funded", Value: 100000000, Metadata: leaderboard.EventMetadata{"project_name": "Karathuru"}},
+			{Rule: "transfer_clawback", Value: -50000000, Metadata: leaderboard.EventMetadata{"project_name": "Karathuru"}},
+			{Rule: "grant", Value: 25000000, Metadata: leaderboard.EventMetadata{}},
+		},
+	}
+
+	It("should split score by category, rule and project", func() {
+		scores := leaderboard.LineScores(line, nil)
+		Expect(lineScore(scores, leaderboard.CategoryDimension, leaderboard.FundCategory)).To(Equal(int64(300)))
+		Expect(lineScore(scores, leaderboard.CategoryDimension, leaderboard.OtherCategory)).To(Equal(int64(25)))
+		Expect(lineScore(scores, leaderboard.RuleDimension, "amount_funded")).To(Equal(int64(300)))
+		Expect(lineScore(scores, leaderboard.RuleDimension, "grant")).To(Equal(int64(25)))
+		Expect(lineScore(scores, leaderboard.ProjectDimension, "Karathuru")).To(Equal(int64(50)))
+	})
+
+	It("should leave out empty and negative scores", func() {
+		scores := leaderboard.LineScores(line, nil)
+		Expect(lineScore(scores, leaderboard.CategoryDimension, leaderboard.FarmingCategory)).To(Equal(int64(-1)))
+		Expect(lineScore(scores, leaderboard.RuleDimension, "transfer_clawback")).To(Equal(int64(-1)))
+		Expect(lineScore(scores, leaderboard.ProjectDimension, "")).To(Equal(int64(-1)))
+	})
+
+	It("should record holdings by project and slot", func() {
+		holdings := []leaderboard.ProjectHolding{
+			{Project: "Banegas Farm", Slot: "0x1", Value: u256.NewInt(1500000000)},
+			{Project: "Karathuru", Slot: "0x3", Value: u256.NewInt(100)},
+		}
+		scores := leaderboard.LineScores(line, holdings)
+		Expect(lineScore(scores, leaderboard.HeldProjectDimension, "Banegas Farm")).To(Equal(int64(1500)))
+		Expect(lineScore(scores, leaderboard.HeldSlotDimension, leaderboard.HeldSlotKey("Banegas Farm", "0x1"))).To(Equal(int64(1500)))
+		Expect(lineScore(scores, leaderboard.HeldSlotDimension, "0x1")).To(Equal(int64(-1)))
+		// less than 1$ is still held
+		Expect(lineScore(scores, leaderboard.HeldProjectDimension, "Karathuru")).To(Equal(int64(1)))
+	})
+})
+
+var _ = Describe("RankingView", func() {
+	It("should require a known dimension and a key to sort by", func() {
+		Expect(leaderboard.RankingView{}.Validate()).To(Succeed())
+		Expect(leaderboard.RankingView{Project: "Karathuru"}.Validate()).To(Succeed())
+		Expect(leaderboard.RankingView{Dimension: leaderboard.RuleDimension, Key: "offseter"}.Validate()).To(Succeed())
+		Expect(leaderboard.RankingView{Dimension: leaderboard.RuleDimension}.Validate()).To(MatchError(leaderboard.ErrInvalidRankingView))
+		Expect(leaderboard.RankingView{Dimension: leaderboard.HeldProjectDimension, Key: "Karathuru"}.Validate()).To(MatchError(leaderboard.ErrInvalidRankingView))
+	})
+
+	It("should require a project along with a slot", func() {
+		Expect(leaderboard.RankingView{Project: "Karathuru", Slot: "0x1"}.Validate()).To(Succeed())
+		Expect(leaderboard.RankingView{Slot: "0x1"}.Validate()).To(MatchError(leaderboard.ErrSlotWithoutProject))
+	})
+
+	It("should match project and slot on the same held slot", func() {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+		Expect(err).NotTo(HaveOccurred())
+		var sql string
+		Expect(db.Callback().Query().After("gorm:query").Register("capture", func(tx *gorm.DB) {
+			sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+		})).To(Succeed())
+
+		_, _, err = leaderboard.GetRankingViewPage(db, nil, leaderboard.RankingView{Project: "Karathuru", Slot: "0x1"}, 0, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(sql, "EXISTS")).To(Equal(1))
+		Expect(sql).To(ContainSubstring("h.dimension = 'held_slot' AND h.key = 'Karathuru#0x1'"))
+	})
+})
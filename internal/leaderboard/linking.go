@@ -0,0 +1,175 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const WalletLinkedEventName string = "wallet:linked"
+
+var (
+	ErrSelfLink            = errors.New("wallet cannot be linked to itself")
+	ErrWalletAlreadyLinked = errors.New("wallet is already linked to an identity")
+	ErrWalletHasLinks      = errors.New("wallet has linked wallets, unlink them first")
+	ErrWalletNotLinked     = errors.New("wallet is not linked")
+	ErrUnlinkNotAllowed    = errors.New("only linked wallet or its identity can unlink it")
+	ErrLinkedByReferral    = errors.New("wallet has a referral with a wallet of the identity")
+)
+
+// Address linked to an identity wallet, its events are ranked with the identity ones.
+// Identity wallets are never linked themselves so that identities do not chain
+type WalletLink struct {
+	CreatedAt      time.Time
+	WalletAddress  string    `gorm:"unique"`
+	IdentityWallet string    `gorm:"index"`
+	ID             ulid.ULID `gorm:"primaryKey"`
+}
+
+// Signed by both identity and wallet to prove control of both accounts, nonce and expiry prevent replays
+func WalletLinkMessage(identity string, wallet string, nonce *felt.Felt, expiry uint64) starknet.TypedMessage {
+	return starknet.TypedMessage{Type: "WalletLink(identity:felt,wallet:felt,nonce:felt,expiry:felt)", Fields: []*felt.Felt{starknet.FeltFromString(identity), starknet.FeltFromString(wallet), nonce, starknet.FeltFromUint64(expiry)}}
+}
+
+// Signed by linked wallet or its identity, nonce and expiry prevent replays
+func WalletUnlinkMessage(wallet string, nonce *felt.Felt, expiry uint64) starknet.TypedMessage {
+	return starknet.TypedMessage{Type: "WalletUnlink(wallet:felt,nonce:felt,expiry:felt)", Fields: []*felt.Felt{starknet.FeltFromString(wallet), nonce, starknet.FeltFromUint64(expiry)}}
+}
+
+func LinkWallet(db *gorm.DB, identity string, wallet string, at time.Time) (*WalletLink, error) {
+	identity = starknet.NormalizeAddress(identity).String()
+	wallet = starknet.NormalizeAddress(wallet).String()
+	if starknet.SameAddress(identity, wallet) {
+		return nil, ErrSelfLink
+	}
+
+	var count int64
+	if err := db.Model(&WalletLink{}).Where("wallet_address IN ?", []string{identity, wallet}).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrWalletAlreadyLinked
+	}
+	if err := db.Model(&WalletLink{}).Where("identity_wallet = ?", wallet).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrWalletHasLinks
+	}
+	// NOTE: referral points between wallets of the same user would be farmed
+	members := []string{identity}
+	links, err := GetLinkedWallets(db, identity)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range links {
+		members = append(members, l.WalletAddress)
+	}
+	if err := db.Model(&Referral{}).Where("(referee_wallet = ? AND referrer_wallet IN ?) OR (referrer_wallet = ? AND referee_wallet IN ?)", wallet, members, wallet, members).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrLinkedByReferral
+	}
+
+	link := WalletLink{CreatedAt: at, WalletAddress: wallet, IdentityWallet: identity, ID: ulid.Make()}
+	if err := db.Create(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Remove wallet from its identity, signer has to be the wallet or the identity
+func UnlinkWallet(db *gorm.DB, wallet string, signer string) (*WalletLink, error) {
+	var link WalletLink
+	err := db.Where("wallet_address = ?", wallet).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWalletNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !starknet.SameAddress(signer, link.WalletAddress) && !starknet.SameAddress(signer, link.IdentityWallet) {
+		return nil, ErrUnlinkNotAllowed
+	}
+	if err := db.Delete(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Identity wallet is ranked, wallet itself when it is not linked
+func IdentityOf(db *gorm.DB, wallet string) (string, error) {
+	var link WalletLink
+	err := db.Where("wallet_address = ?", wallet).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return wallet, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return link.IdentityWallet, nil
+}
+
+// Wallets linked to identity
+func GetLinkedWallets(db *gorm.DB, identity string) ([]WalletLink, error) {
+	links := []WalletLink{}
+	err := db.Where("identity_wallet = ?", identity).Order("created_at ASC").Find(&links).Error
+	return links, err
+}
+
+func GetWalletLinks(db *gorm.DB) ([]WalletLink, error) {
+	var links []WalletLink
+	err := db.Find(&links).Error
+	return links, err
+}
+
+// Group wallets by identity, identity wallet comes first in each group
+func GroupByIdentity(wallets []string, links []WalletLink) map[string][]string {
+	identities := make(map[string]string)
+	for _, l := range links {
+		identities[l.WalletAddress] = l.IdentityWallet
+	}
+
+	groups := make(map[string][]string)
+	for _, w := range wallets {
+		identity, linked := identities[w]
+		if !linked {
+			identity = w
+		}
+		if _, exists := groups[identity]; !exists {
+			groups[identity] = []string{identity}
+		}
+		if w != identity {
+			groups[identity] = append(groups[identity], w)
+		}
+	}
+	return groups
+}
+
+// Event added to identity history so that history builders know wallets belong to the same user
+func WalletLinkedEvent(l WalletLink) DomainEvent {
+	return DomainEvent{
+		RecordedAt:    l.CreatedAt,
+		EventName:     WalletLinkedEventName,
+		WalletAddress: l.IdentityWallet,
+		Data:          EventData{"identity": l.IdentityWallet, "wallet": l.WalletAddress},
+		Metadata:      EventMetadata{},
+	}
+}
+
+// Wallets linked together in history, indexed by normalized address
+func linkedWallets(events []DomainEvent) map[string]bool {
+	linked := make(map[string]bool)
+	for _, e := range events {
+		if e.EventName == WalletLinkedEventName {
+			linked[normalizeFelt(e.Data["identity"])] = true
+			linked[normalizeFelt(e.Data["wallet"])] = true
+		}
+	}
+	return linked
+}
@@ -0,0 +1,54 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Wallet linking", func() {
+	link := leaderboard.WalletLink{CreatedAt: time.Unix(t0, 0), WalletAddress: buyer, IdentityWallet: holder}
+
+	It("should group wallets by identity", func() {
+		groups := leaderboard.GroupByIdentity([]string{buyer, "0x3", holder}, []leaderboard.WalletLink{link})
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[holder]).To(Equal([]string{holder, buyer}))
+		Expect(groups["0x3"]).To(Equal([]string{"0x3"}))
+	})
+
+	It("should rank identity with a breakdown per wallet", func() {
+		scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithBuilders(&leaderboard.AmountFundedScoreCalculator{}))
+		holderBuy := newMinterBuyEvt("buy_1", map[string]string{"address": holder, "value": "0x3b9aca00"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, t0)
+		buyerBuy := newMinterBuyEvt("buy_2", map[string]string{"address": buyer, "value": "0x1dcd6500"}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, t0+day)
+		buyerBuy.WalletAddress = buyer
+
+		line := leaderboard.NewPersonnalRanking(holder, []leaderboard.DomainEvent{holderBuy, buyerBuy}).WithLinkedWallets(buyer).ComputeScore(scm)
+		Expect(line.WalletAddress).To(Equal(holder))
+		Expect(line.TotalScore).To(Equal("1500"))
+		Expect(line.Wallets).To(Equal(leaderboard.WalletScores{
+			{WalletAddress: holder, TotalScore: "1000"},
+			{WalletAddress: buyer, TotalScore: "500"},
+		}))
+		Expect(line.Points[1].Metadata["wallet"]).To(Equal(buyer))
+	})
+
+	It("should not claw back tokens sent to a linked wallet", func() {
+		value := "0x3b9aca00"
+		events := append(mintTokenEvts("0x1", value, t0), buyProjectEvt("Banegas Farm", 1000*1000000), transferTokenEvt("0x1", buyer, t0+day), leaderboard.WalletLinkedEvent(link))
+		Expect(clawbackScore(events).TotalScore).To(Equal("1000"))
+	})
+
+	It("should sign nonce and expiry along with link and unlink", func() {
+		first, _ := leaderboard.ParseNonce("0x1")
+		second, _ := leaderboard.ParseNonce("0x2")
+
+		linkMsg := leaderboard.WalletLinkMessage(holder, buyer, first, uint64(t0))
+		Expect(linkMsg.Hash()).NotTo(Equal(leaderboard.WalletLinkMessage(holder, buyer, second, uint64(t0)).Hash()))
+		Expect(linkMsg.Hash()).NotTo(Equal(leaderboard.WalletLinkMessage(holder, buyer, first, uint64(t0+1)).Hash()))
+
+		unlinkMsg := leaderboard.WalletUnlinkMessage(buyer, first, uint64(t0))
+		Expect(unlinkMsg.Hash()).NotTo(Equal(leaderboard.WalletUnlinkMessage(buyer, second, uint64(t0)).Hash()))
+	})
+})
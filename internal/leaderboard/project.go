@@ -0,0 +1,75 @@
+package leaderboard
+
+import (
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// Ranking of the supporters of one project, computed with full rules on project events only
+type ProjectLeaderboardLine struct {
+	LeaderboardLine
+	Project string `gorm:"index"`
+}
+
+func ProjectLeaderboardLineFromLine(project string, line *LeaderboardLine) ProjectLeaderboardLine {
+	return ProjectLeaderboardLine{
+		LeaderboardLine: *line,
+		Project:         project,
+	}
+}
+
+// Names of projects events belong to, sorted
+func EventProjects(events []DomainEvent) []string {
+	seen := make(map[string]bool)
+	var projects []string
+	for _, e := range events {
+		p := e.Metadata["project_name"]
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	return projects
+}
+
+// Keep only events of project. Referral and wallet links are kept as they tell how project events are scored
+func ProjectEvents(project string, events []DomainEvent) []DomainEvent {
+	var res []DomainEvent
+	for _, e := range events {
+		if e.Metadata["project_name"] == project || e.EventName == "referral:linked" || e.EventName == WalletLinkedEventName {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Project lines computed during an aggregator run
+type projectRun struct {
+	lines []ProjectLeaderboardLine
+	mu    sync.Mutex
+}
+
+func (pr *projectRun) add(line ProjectLeaderboardLine) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.lines = append(pr.lines, line)
+}
+
+// Replace every project line and rank them by project
+func (pr *projectRun) save(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&ProjectLeaderboardLine{}).Error; err != nil {
+			return err
+		}
+		if len(pr.lines) > 0 {
+			if err := tx.CreateInBatches(pr.lines, 100).Error; err != nil {
+				return err
+			}
+		}
+		return rankTable(tx, "project_leaderboard_lines", "l.project,")
+	})
+}
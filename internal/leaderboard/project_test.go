@@ -0,0 +1,37 @@
+package leaderboard_test
+
+import (
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Project leaderboard", func() {
+	banegas := buyProjectEvt("Banegas Farm", 100*1000000)
+	karathuru := buyProjectEvt("Karathuru", 50*1000000)
+	linked := leaderboard.DomainEvent{EventName: "referral:linked", WalletAddress: holder, Metadata: leaderboard.EventMetadata{}}
+	grant := leaderboard.DomainEvent{EventName: leaderboard.GrantEventName, WalletAddress: holder, Metadata: leaderboard.EventMetadata{}}
+
+	It("should list projects of events once", func() {
+		events := []leaderboard.DomainEvent{karathuru, banegas, karathuru, grant}
+		Expect(leaderboard.EventProjects(events)).To(Equal([]string{"Banegas Farm", "Karathuru"}))
+	})
+
+	It("should keep project events and links only", func() {
+		events := leaderboard.ProjectEvents("Karathuru", []leaderboard.DomainEvent{banegas, karathuru, linked, grant})
+		Expect(events).To(HaveLen(2))
+		Expect(events[0].Metadata["project_name"]).To(Equal("Karathuru"))
+		Expect(events[1].EventName).To(Equal("referral:linked"))
+	})
+
+	It("should score project events only", func() {
+		scm := leaderboard.MintPageCalculatorManager()
+		all := leaderboard.NewPersonnalRanking(holder, []leaderboard.DomainEvent{banegas, karathuru}).ComputeScore(scm)
+		project := leaderboard.NewPersonnalRanking(holder, leaderboard.ProjectEvents("Banegas Farm", []leaderboard.DomainEvent{banegas, karathuru})).ComputeScore(scm)
+
+		line := leaderboard.ProjectLeaderboardLineFromLine("Banegas Farm", project)
+		Expect(line.Project).To(Equal("Banegas Farm"))
+		Expect(line.Points).To(HaveLen(1))
+		Expect(line.Score).To(BeNumerically("<", all.Score))
+	})
+})
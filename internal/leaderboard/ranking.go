@@ -0,0 +1,225 @@
+package leaderboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// Lines are ranked by score, earliest activity first on ties and wallet address so that rank is total.
+	// NOTE: tie-break is part of the rank on purpose, the earliest supporter wins a tie and keyset
+	// cursors page on rank > position, which would skip tied lines with RANK() or DENSE_RANK().
+	// Only rows whose rank changed are rewritten
+	rankLinesQuery string = `UPDATE %[1]s l SET rank = r.rank FROM (
+	SELECT l.id,
+	CASE WHEN e.wallet_address IS NULL THEN ROW_NUMBER() OVER(PARTITION BY %[2]s e.wallet_address IS NULL ORDER BY l.score DESC, l.first_activity_at ASC NULLS LAST, l.wallet_address ASC) ELSE 0 END AS rank
+	FROM %[1]s l LEFT JOIN wallet_exclusions e ON e.wallet_address = l.wallet_address) r
+WHERE l.id = r.id AND l.rank IS DISTINCT FROM r.rank`
+
+	// Filters out excluded wallets from ranking queries
+	RankedLineCondition string = "rank > 0"
+
+	MaxPageSize     int = 100
+	MaxAroundRadius int = 50
+)
+
+var (
+	ErrInvalidCursor      = errors.New("invalid cursor")
+	ErrInvalidPage        = errors.New("page must be 1 or more")
+	ErrInvalidPageSize    = fmt.Errorf("page size must be between 1 and %d", MaxPageSize)
+	ErrInvalidRadius      = fmt.Errorf("radius must be between 0 and %d", MaxAroundRadius)
+	ErrLastRequiresBefore = errors.New("last can only be used along with before")
+)
+
+// Line with its position in ranking, excluded wallets have position 0
+type RankedLine struct {
+	LeaderboardLine
+	Position int
+}
+
+// Keyset cursor on the rank stored by aggregator
+type Cursor struct {
+	Position int
+}
+
+func CursorFromLine(l RankedLine) Cursor {
+	return Cursor{Position: l.Position}
+}
+
+func (c Cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("rank|%d", c.Position)))
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(b), "|")
+	if len(parts) != 2 || parts[0] != "rank" {
+		return nil, ErrInvalidCursor
+	}
+	position, err := strconv.Atoi(parts[1])
+	if err != nil || position < 1 {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Position: position}, nil
+}
+
+// Rank all-time lines, lines of every season and of every project
+func UpdateRanks(db *gorm.DB) error {
+	if err := rankTable(db, "leaderboard_lines", ""); err != nil {
+		return err
+	}
+	if err := rankTable(db, "season_leaderboard_lines", "l.season_id,"); err != nil {
+		return err
+	}
+	return rankTable(db, "project_leaderboard_lines", "l.project,")
+}
+
+// Fill score and rank of lines computed before they were stored.
+// Earliest activity is left empty until next aggregator run
+func BackfillRanks(db *gorm.DB) error {
+	for _, table := range []string{"leaderboard_lines", "season_leaderboard_lines"} {
+		err := db.Exec(fmt.Sprintf("UPDATE %s SET score = LEAST(total_score::NUMERIC, ?)::BIGINT WHERE score IS NULL OR score = 0", table), int64(math.MaxInt64)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return UpdateRanks(db)
+}
+
+func rankTable(db *gorm.DB, table string, partition string) error {
+	return db.Exec(fmt.Sprintf(rankLinesQuery, table, partition)).Error
+}
+
+// Ranked lines of all-time leaderboard or of season when seasonID is set
+func rankingScope(seasonID *ulid.ULID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if seasonID != nil {
+			db = db.Model(&SeasonLeaderboardLine{}).Where("season_id = ?", *seasonID)
+		} else {
+			db = db.Model(&LeaderboardLine{})
+		}
+		return db.Where(RankedLineCondition)
+	}
+}
+
+type RankingPage struct {
+	Lines           []RankedLine
+	HasNextPage     bool
+	HasPreviousPage bool
+}
+
+// Lines of total ranking keyed on their stored rank, or of view keyed on their computed position
+func rankingQuery(db *gorm.DB, seasonID *ulid.ULID, view RankingView) (*gorm.DB, string) {
+	if view.IsDefault() {
+		return db.Scopes(rankingScope(seasonID)).Select("*, rank AS position"), "rank"
+	}
+	return view.query(db, seasonID), "position"
+}
+
+// Page of first lines after cursor, or last lines before cursor when last is set
+func GetRankingPage(db *gorm.DB, seasonID *ulid.ULID, view RankingView, first int, after *Cursor, last int, before *Cursor) (*RankingPage, error) {
+	if err := view.Validate(); err != nil {
+		return nil, err
+	}
+	if last > 0 {
+		if before == nil {
+			return nil, ErrLastRequiresBefore
+		}
+		return rankingPageBefore(db, seasonID, view, last, before)
+	}
+	if first < 1 || first > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	q, position := rankingQuery(db, seasonID, view)
+	if after != nil {
+		q = q.Where(position+" > ?", after.Position)
+	}
+	lines := []RankedLine{}
+	if err := q.Order(position + " ASC").Limit(first + 1).Find(&lines).Error; err != nil {
+		return nil, err
+	}
+
+	page := &RankingPage{HasPreviousPage: after != nil}
+	if len(lines) > first {
+		lines = lines[:first]
+		page.HasNextPage = true
+	}
+	page.Lines = lines
+	return page, nil
+}
+
+func rankingPageBefore(db *gorm.DB, seasonID *ulid.ULID, view RankingView, last int, before *Cursor) (*RankingPage, error) {
+	if last > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	q, position := rankingQuery(db, seasonID, view)
+	lines := []RankedLine{}
+	err := q.Where(position+" < ?", before.Position).Order(position + " DESC").Limit(last + 1).Find(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &RankingPage{HasNextPage: true}
+	if len(lines) > last {
+		lines = lines[:last]
+		page.HasPreviousPage = true
+	}
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	page.Lines = lines
+	return page, nil
+}
+
+// Wallet line surrounded by radius lines above and below it.
+// Excluded wallets only get their own line as they are not ranked
+func GetRankingAround(db *gorm.DB, seasonID *ulid.ULID, wallet string, radius int) ([]RankedLine, error) {
+	if radius < 0 || radius > MaxAroundRadius {
+		return nil, ErrInvalidRadius
+	}
+
+	var line LeaderboardLine
+	q := db.Model(&LeaderboardLine{})
+	if seasonID != nil {
+		q = db.Model(&SeasonLeaderboardLine{}).Where("season_id = ?", *seasonID)
+	}
+	err := q.Where("wallet_address = ?", wallet).First(&line).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return []RankedLine{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if line.Rank == 0 {
+		return rankLines([]LeaderboardLine{line}), nil
+	}
+
+	var lines []LeaderboardLine
+	err = db.Scopes(rankingScope(seasonID)).
+		Where("rank BETWEEN ? AND ?", line.Rank-radius, line.Rank+radius).
+		Order("rank ASC").Find(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return rankLines(lines), nil
+}
+
+func rankLines(lines []LeaderboardLine) []RankedLine {
+	ranked := []RankedLine{}
+	for _, l := range lines {
+		ranked = append(ranked, RankedLine{LeaderboardLine: l, Position: l.Rank})
+	}
+	return ranked
+}
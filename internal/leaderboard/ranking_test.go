@@ -0,0 +1,47 @@
+package leaderboard_test
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cursor", func() {
+	It("should round trip through its encoded form", func() {
+		line := leaderboard.RankedLine{LeaderboardLine: leaderboard.LeaderboardLine{WalletAddress: holder, Score: 1250, Rank: 42}, Position: 42}
+		cursor, err := leaderboard.DecodeCursor(leaderboard.CursorFromLine(line).Encode())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*cursor).To(Equal(leaderboard.Cursor{Position: 42}))
+	})
+
+	It("should reject tampered cursors", func() {
+		for _, c := range []string{"not base64 !", "MTAwfDB4MXw0Mg", "cmFua3xhYmM", "cmFua3ww"} {
+			_, err := leaderboard.DecodeCursor(c)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidCursor), c)
+		}
+	})
+})
+
+var _ = Describe("Ranked line", func() {
+	It("should store numeric score and earliest activity of wallet", func() {
+		first := buyProjectEvt("Karathuru", 100*1000000)
+		first.RecordedAt = time.Unix(t0, 0)
+		second := buyProjectEvt("Karathuru", 100*1000000)
+		second.RecordedAt = time.Unix(t0+day, 0)
+
+		scm := leaderboard.MintPageCalculatorManager()
+		line := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{second, first}).ComputeScore(scm)
+		Expect(strconv.FormatInt(line.Score, 10)).To(Equal(line.TotalScore))
+		Expect(line.Score).To(BeNumerically(">", 0))
+		Expect(*line.FirstActivityAt).To(Equal(time.Unix(t0, 0)))
+	})
+
+	It("should have no first activity without events", func() {
+		line := leaderboard.NewPersonnalRanking(holder, nil).ComputeScore(leaderboard.NewScoreCalculatorManager())
+		Expect(line.Score).To(Equal(int64(0)))
+		Expect(line.FirstActivityAt).To(BeNil())
+	})
+})
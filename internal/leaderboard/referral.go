@@ -0,0 +1,216 @@
+package leaderboard
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	ReferralSourceSignature string = "signature"
+	ReferralSourceOnChain   string = "onchain"
+
+	defaultReferralShare uint64 = 10
+)
+
+var (
+	ErrInvalidReferralCode = errors.New("referral code must be 4 to 31 letters, digits, dashes or underscores")
+	ErrReferralCodeTaken   = errors.New("referral code already registered")
+	ErrUnknownReferralCode = errors.New("unknown referral code")
+	ErrAlreadyReferred     = errors.New("wallet has already been referred")
+	ErrSelfReferral        = errors.New("wallet cannot refer itself")
+	ErrReferralCycle       = errors.New("referrer has been referred by this wallet")
+	ErrSameIdentity        = errors.New("referee and referrer belong to the same identity")
+
+	referralCodeRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{4,31}$`)
+)
+
+// Signed by referrer to register a code
+func ReferralCodeMessage(code string) starknet.TypedMessage {
+	return starknet.TypedMessage{Type: "ReferralCode(code:felt)", Fields: []*felt.Felt{starknet.ShortStringToFelt(code)}}
+}
+
+// Signed by referee to be linked to the owner of code, nonce and expiry prevent replays
+func ReferralClaimMessage(code string, nonce *felt.Felt, expiry uint64) starknet.TypedMessage {
+	return starknet.TypedMessage{Type: "ReferralClaim(code:felt,nonce:felt,expiry:felt)", Fields: []*felt.Felt{starknet.ShortStringToFelt(code), nonce, starknet.FeltFromUint64(expiry)}}
+}
+
+// Code a wallet shares to bring in new buyers
+type ReferralCode struct {
+	CreatedAt     time.Time
+	Code          string    `gorm:"unique"`
+	WalletAddress string    `gorm:"index"`
+	ID            ulid.ULID `gorm:"primaryKey"`
+}
+
+// Link between a referred wallet and its referrer. A wallet can only be referred once
+type Referral struct {
+	CreatedAt      time.Time
+	RefereeWallet  string `gorm:"unique"`
+	ReferrerWallet string `gorm:"index"`
+	Code           string
+	Source         string
+	// Event the referral comes from when it was registered on-chain
+	EventId string
+	ID      ulid.ULID `gorm:"primaryKey"`
+}
+
+func RegisterReferralCode(db *gorm.DB, wallet string, code string) (*ReferralCode, error) {
+	if !referralCodeRegexp.MatchString(code) {
+		return nil, ErrInvalidReferralCode
+	}
+	wallet = starknet.NormalizeAddress(wallet).String()
+
+	var existing ReferralCode
+	err := db.Where("code = ?", code).First(&existing).Error
+	if err == nil {
+		if starknet.SameAddress(existing.WalletAddress, wallet) {
+			return &existing, nil
+		}
+		return nil, ErrReferralCodeTaken
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rc := ReferralCode{CreatedAt: time.Now(), Code: code, WalletAddress: wallet, ID: ulid.Make()}
+	if err := db.Create(&rc).Error; err != nil {
+		return nil, err
+	}
+	return &rc, nil
+}
+
+// Link referee to the owner of code
+func ClaimReferral(db *gorm.DB, referee string, code string, at time.Time) (*Referral, error) {
+	var rc ReferralCode
+	err := db.Where("code = ?", code).First(&rc).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownReferralCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return saveReferral(db, Referral{CreatedAt: at, RefereeWallet: referee, ReferrerWallet: rc.WalletAddress, Code: code, Source: ReferralSourceSignature, ID: ulid.Make()})
+}
+
+// Link referee to referrer from an on-chain referral event
+func ReferralFromEvent(db *gorm.DB, e DomainEvent) (*Referral, error) {
+	return saveReferral(db, Referral{CreatedAt: e.RecordedAt, RefereeWallet: e.Data["referee"], ReferrerWallet: e.Data["referrer"], Source: ReferralSourceOnChain, EventId: e.EventId, ID: ulid.Make()})
+}
+
+func saveReferral(db *gorm.DB, r Referral) (*Referral, error) {
+	r.RefereeWallet = starknet.NormalizeAddress(r.RefereeWallet).String()
+	r.ReferrerWallet = starknet.NormalizeAddress(r.ReferrerWallet).String()
+	if starknet.SameAddress(r.RefereeWallet, r.ReferrerWallet) {
+		return nil, ErrSelfReferral
+	}
+
+	var count int64
+	if err := db.Model(&Referral{}).Where("referee_wallet = ?", r.RefereeWallet).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAlreadyReferred
+	}
+	if err := db.Model(&Referral{}).Where("referee_wallet = ? AND referrer_wallet = ?", r.ReferrerWallet, r.RefereeWallet).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrReferralCycle
+	}
+	referrerIdentity, err := IdentityOf(db, r.ReferrerWallet)
+	if err != nil {
+		return nil, err
+	}
+	refereeIdentity, err := IdentityOf(db, r.RefereeWallet)
+	if err != nil {
+		return nil, err
+	}
+	if starknet.SameAddress(referrerIdentity, refereeIdentity) {
+		return nil, ErrSameIdentity
+	}
+
+	if err := db.Create(&r).Error; err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func GetReferralCodes(db *gorm.DB, wallet string) ([]ReferralCode, error) {
+	var codes []ReferralCode
+	err := db.Where("wallet_address = ?", wallet).Order("created_at ASC").Find(&codes).Error
+	return codes, err
+}
+
+func GetReferees(db *gorm.DB, referrer string) ([]Referral, error) {
+	var referrals []Referral
+	err := db.Where("referrer_wallet = ?", referrer).Order("created_at ASC").Find(&referrals).Error
+	return referrals, err
+}
+
+// Event added to referrer history so that referral builder knows since when referee purchases count
+func ReferralLinkedEvent(r Referral) DomainEvent {
+	return DomainEvent{
+		RecordedAt:    r.CreatedAt,
+		EventName:     "referral:linked",
+		WalletAddress: r.ReferrerWallet,
+		Data:          EventData{"referee": r.RefereeWallet, "code": r.Code, "source": r.Source},
+		Metadata:      EventMetadata{},
+	}
+}
+
+// Referral - Referrer earns a share of what its referees funded after being referred.
+// Share is computed on funded value, boosts of referees are not shared.
+type ReferralScoreCalculator struct {
+	// Percent of referee funded points given to referrer, defaults to 10
+	Share *uint64
+}
+
+func (sc *ReferralScoreCalculator) share() uint64 {
+	return settingOr(sc.Share, defaultReferralShare)
+}
+
+func (sc *ReferralScoreCalculator) ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time) []Score {
+	// referees linked to the same identity do not earn referral points
+	identity := linkedWallets(events)
+	linkedAt := make(map[string]time.Time)
+	for _, e := range events {
+		if identity[normalizeFelt(wallet)] && identity[normalizeFelt(e.Data["referee"])] {
+			continue
+		}
+		if e.EventName == "referral:linked" && starknet.SameAddress(e.WalletAddress, wallet) {
+			linkedAt[normalizeFelt(e.Data["referee"])] = e.RecordedAt
+		}
+	}
+	if len(linkedAt) == 0 {
+		return nil
+	}
+
+	var scores []Score
+	for _, e := range events {
+		if e.EventName != "minter:buy" && e.EventName != "minter:airdrop" {
+			continue
+		}
+		at, referred := linkedAt[normalizeFelt(e.WalletAddress)]
+		if !referred || e.RecordedAt.Before(at) || e.RecordedAt.Before(from) || e.RecordedAt.After(until) {
+			continue
+		}
+		value, err := u256.FromHex(e.Data["value"])
+		if err != nil {
+			continue
+		}
+		points := mulCoeficient(value, u256.NewInt(sc.share()))
+		if points.IsZero() {
+			continue
+		}
+		scores = append(scores, Score{Points: points, Rule: ReferralRuleName, Event: e})
+	}
+	return scores
+}
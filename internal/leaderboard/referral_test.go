@@ -0,0 +1,47 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func referralLine(events []leaderboard.DomainEvent) *leaderboard.LeaderboardLine {
+	scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithHistoryBuilders(&leaderboard.ReferralScoreCalculator{Share: setting(10)}))
+	return leaderboard.NewPersonnalRanking(holder, nil).WithHistory(events, time.Time{}, time.Unix(t0+10*day, 0)).ComputeScore(scm)
+}
+
+func refereeBuyEvt(id string, value string, ts int64) leaderboard.DomainEvent {
+	e := newEvent(id, "minter:buy", map[string]string{"address": buyer, "value": value}, map[string]string{"slot": "0x1", "project_name": "Banegas Farm"}, []string{}, ts)
+	e.WalletAddress = buyer
+	return e
+}
+
+var _ = Describe("ReferralScoreCalculator", func() {
+	linked := leaderboard.ReferralLinkedEvent(leaderboard.Referral{CreatedAt: time.Unix(t0+day, 0), RefereeWallet: buyer, ReferrerWallet: holder, Code: "carbon"})
+
+	It("should share referee funded value with referrer", func() {
+		// 1000$
+		line := referralLine([]leaderboard.DomainEvent{linked, refereeBuyEvt("buy", "0x3b9aca00", t0+2*day)})
+		Expect(line.TotalScore).To(Equal("100"))
+		Expect(line.Categories.Other).To(Equal("100"))
+	})
+
+	It("should ignore purchases made before referral", func() {
+		line := referralLine([]leaderboard.DomainEvent{refereeBuyEvt("buy", "0x3b9aca00", t0), linked})
+		Expect(line.TotalScore).To(Equal("0"))
+	})
+
+	It("should ignore purchases of referees linked to the same identity", func() {
+		link := leaderboard.WalletLinkedEvent(leaderboard.WalletLink{CreatedAt: time.Unix(t0+day, 0), WalletAddress: buyer, IdentityWallet: holder})
+		line := referralLine([]leaderboard.DomainEvent{linked, link, refereeBuyEvt("buy", "0x3b9aca00", t0+2*day)})
+		Expect(line.TotalScore).To(Equal("0"))
+	})
+
+	It("should ignore purchases of wallets that were not referred", func() {
+		line := referralLine([]leaderboard.DomainEvent{refereeBuyEvt("buy", "0x3b9aca00", t0+2*day)})
+		Expect(line.TotalScore).To(Equal("0"))
+	})
+})
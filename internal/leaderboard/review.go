@@ -0,0 +1,131 @@
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"gorm.io/gorm"
+)
+
+const (
+	// Wallet sent project tokens to many wallets
+	CommonFundingSourceFlag string = "common_funding_source"
+	// Same token went back and forth between two wallets
+	TokenBounceFlag string = "token_bounce"
+
+	DefaultMinFundedWallets int = 5
+)
+
+// Heuristic flag raised on a wallet for manual review, flagged wallets are not excluded automatically
+type WalletFlag struct {
+	WalletAddress string   `json:"wallet_address"`
+	Flag          string   `json:"flag"`
+	Details       string   `json:"details"`
+	Related       []string `json:"related"`
+}
+
+// Flag suspicious patterns in project token transfers. Mints, burns and ignored wallets are skipped.
+// minFunded is the number of distinct recipients from which a sender is flagged
+func DetectFlags(transfers []DomainEvent, minFunded int, ignored []string) []WalletFlag {
+	skip := func(address string) bool {
+		if starknet.SameAddress(address, "0x0") {
+			return true
+		}
+		for _, i := range ignored {
+			if starknet.SameAddress(i, address) {
+				return true
+			}
+		}
+		return false
+	}
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		return transfers[i].RecordedAt.Before(transfers[j].RecordedAt)
+	})
+
+	recipients := make(map[string]map[string]bool)
+	// previous transfer of each token
+	last := make(map[string]DomainEvent)
+	bounces := make(map[[2]string]int)
+	for _, e := range transfers {
+		if e.EventName != "project:transfer" {
+			continue
+		}
+		from, to := normalizeFelt(e.Data["from"]), normalizeFelt(e.Data["to"])
+		if skip(from) || skip(to) || from == to {
+			continue
+		}
+
+		if _, exists := recipients[from]; !exists {
+			recipients[from] = make(map[string]bool)
+		}
+		recipients[from][to] = true
+
+		token := normalizeFelt(e.FromAddress) + "#" + normalizeFelt(e.Data["token_id"])
+		if prev, exists := last[token]; exists && normalizeFelt(prev.Data["from"]) == to && normalizeFelt(prev.Data["to"]) == from {
+			pair := [2]string{from, to}
+			if to < from {
+				pair = [2]string{to, from}
+			}
+			bounces[pair]++
+		}
+		last[token] = e
+	}
+
+	var flags []WalletFlag
+	for from, to := range recipients {
+		if len(to) < minFunded {
+			continue
+		}
+		var related []string
+		for w := range to {
+			related = append(related, w)
+		}
+		sort.Strings(related)
+		flags = append(flags, WalletFlag{
+			WalletAddress: from,
+			Flag:          CommonFundingSourceFlag,
+			Details:       fmt.Sprintf("sent project tokens to %d wallets", len(to)),
+			Related:       related,
+		})
+	}
+	for pair, count := range bounces {
+		flags = append(flags, WalletFlag{
+			WalletAddress: pair[0],
+			Flag:          TokenBounceFlag,
+			Details:       fmt.Sprintf("tokens sent back %d times", count),
+			Related:       []string{pair[1]},
+		})
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].Flag != flags[j].Flag {
+			return flags[i].Flag < flags[j].Flag
+		}
+		return flags[i].WalletAddress < flags[j].WalletAddress
+	})
+	return flags
+}
+
+// Flags computed from every project transfer, excluded wallets are not reviewed
+func ReviewWallets(db *gorm.DB, minFunded int) ([]WalletFlag, error) {
+	var transfers []DomainEvent
+	if err := db.Where("event_name = ?", "project:transfer").Order("recorded_at ASC").Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	exclusions, err := GetExclusions(db)
+	if err != nil {
+		return nil, err
+	}
+	var ignored []string
+	for _, e := range exclusions {
+		ignored = append(ignored, e.WalletAddress)
+	}
+
+	flags := DetectFlags(transfers, minFunded, ignored)
+	if flags == nil {
+		flags = []WalletFlag{}
+	}
+	return flags, nil
+}
@@ -0,0 +1,70 @@
+package leaderboard_test
+
+import (
+	"fmt"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func reviewTransferEvt(id string, from string, to string, tokenId string, ts int64) leaderboard.DomainEvent {
+	return newProjectTransferEvt(id, map[string]string{"from": from, "to": to, "token_id": tokenId}, map[string]string{}, []string{}, ts)
+}
+
+var _ = Describe("DetectFlags", func() {
+	It("should flag wallets sending tokens to many wallets", func() {
+		var transfers []leaderboard.DomainEvent
+		for i := 0; i < 3; i++ {
+			transfers = append(transfers, reviewTransferEvt(fmt.Sprintf("t%d", i), "0xa", fmt.Sprintf("0x%d", i+10), fmt.Sprintf("0x%d", i+1), t0+int64(i)))
+		}
+
+		flags := leaderboard.DetectFlags(transfers, 3, nil)
+		Expect(flags).To(HaveLen(1))
+		Expect(flags[0].Flag).To(Equal(leaderboard.CommonFundingSourceFlag))
+		Expect(flags[0].WalletAddress).To(Equal("0xa"))
+		Expect(flags[0].Related).To(HaveLen(3))
+
+		Expect(leaderboard.DetectFlags(transfers, 4, nil)).To(BeEmpty())
+		Expect(leaderboard.DetectFlags(transfers, 3, []string{"0x00a"})).To(BeEmpty())
+	})
+
+	It("should flag tokens bouncing between two wallets", func() {
+		transfers := []leaderboard.DomainEvent{
+			reviewTransferEvt("t1", "0x0", "0xa", "0x1", t0),
+			reviewTransferEvt("t2", "0xa", "0xb", "0x1", t0+1),
+			reviewTransferEvt("t3", "0xb", "0xa", "0x1", t0+2),
+			reviewTransferEvt("t4", "0xa", "0xb", "0x1", t0+3),
+		}
+
+		flags := leaderboard.DetectFlags(transfers, 5, nil)
+		Expect(flags).To(HaveLen(1))
+		Expect(flags[0].Flag).To(Equal(leaderboard.TokenBounceFlag))
+		Expect(flags[0].WalletAddress).To(Equal("0xa"))
+		Expect(flags[0].Related).To(Equal([]string{"0xb"}))
+		Expect(flags[0].Details).To(Equal("tokens sent back 2 times"))
+	})
+
+	It("should not flag tokens sold to different wallets", func() {
+		transfers := []leaderboard.DomainEvent{
+			reviewTransferEvt("t1", "0xa", "0xb", "0x1", t0),
+			reviewTransferEvt("t2", "0xb", "0xc", "0x1", t0+1),
+		}
+		Expect(leaderboard.DetectFlags(transfers, 5, nil)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ExclusionsFromConfig", func() {
+	It("should exclude configured wallets and contracts", func() {
+		exclusions := leaderboard.ExclusionsFromConfig(
+			[]config.Exclusion{{Address: "0x00abc", Reason: "team wallet"}, {Address: "not an address", Reason: "typo"}},
+			[]config.Contract{{Name: "minter_banegas", Address: "0x0def"}},
+		)
+		Expect(exclusions).To(HaveLen(2))
+		Expect(exclusions[0].WalletAddress).To(Equal("0xabc"))
+		Expect(exclusions[0].Source).To(Equal(leaderboard.ExclusionSourceConfig))
+		Expect(exclusions[1].WalletAddress).To(Equal("0xdef"))
+		Expect(exclusions[1].Source).To(Equal(leaderboard.ExclusionSourceContract))
+	})
+})
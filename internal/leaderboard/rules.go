@@ -0,0 +1,256 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/carbonable/leaderboard/internal/config"
+)
+
+const (
+	FullRules     string = "full"
+	MintPageRules string = "mint_page"
+
+	ProjectValueBoostName     string = "project_value"
+	FundingMilestoneBoostName string = "funding_milestone"
+
+	DefaultRulesVersion string = "default"
+)
+
+var (
+	ErrUnknownRules   = errors.New("unknown rule set")
+	ErrInvalidScoring = errors.New("invalid scoring configuration")
+)
+
+// Score calculator managers built from scoring configuration, indexed by rule set name
+type ScoringRules struct {
+	managers map[string]*ScoreCalculatorManager
+	Version  string
+}
+
+// Scoring configuration matching historical hard-coded values, omitted settings use rule defaults
+func DefaultScoringConfig() *config.Scoring {
+	projectValueTiers := []config.Tier{
+		{Step: 5000, Coef: 300},
+		{Step: 1000, Coef: 200},
+		{Step: 500, Coef: 150},
+	}
+	karathuruMilestone := config.Booster{Boost: FundingMilestoneBoostName, Project: "Karathuru", Tiers: []config.Tier{
+		{Step: 50000, Coef: 300},
+		{Step: 150000, Coef: 200},
+		{Step: 300000, Coef: 150},
+		{Step: 500000, Coef: 120},
+		{Step: 700000, Coef: 110},
+	}}
+
+	return &config.Scoring{
+		Version: DefaultRulesVersion,
+		RuleSets: map[string]config.RuleSet{
+			FullRules: {
+				Builders: []config.Builder{
+					{Rule: string(AmountFundRuleName)},
+					{Rule: string(NumberOfProjectsRuleName)},
+					{Rule: string(ResalerRuleName)},
+					{Rule: string(OffseterRuleName)},
+					{Rule: string(EarlyAdopterRuleName), Projects: pointsPerProject},
+				},
+				Boosters: []config.Booster{
+					karathuruMilestone,
+					{Boost: ProjectValueBoostName, Tiers: projectValueTiers},
+				},
+			},
+			MintPageRules: {
+				Builders: []config.Builder{
+					{Rule: string(AmountFundRuleName)},
+				},
+				Boosters: []config.Booster{
+					karathuruMilestone,
+					{Boost: ProjectValueBoostName, Tiers: projectValueTiers},
+				},
+			},
+		},
+	}
+}
+
+func DefaultScoringRules(aggregator BuyValueAggregator) *ScoringRules {
+	rules, err := NewScoringRules(DefaultScoringConfig(), aggregator)
+	if err != nil {
+		// NOTE: default configuration is static, this is a programming error
+		panic(err)
+	}
+	return rules
+}
+
+// Build and validate every rule set of the scoring configuration.
+// Protocol addresses are contracts holding tokens on behalf of wallets (yielder, offseter, migrator)
+func NewScoringRules(cfg *config.Scoring, aggregator BuyValueAggregator, protocolAddresses ...string) (*ScoringRules, error) {
+	if cfg.Version == "" {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidScoring)
+	}
+	if _, exists := cfg.RuleSets[FullRules]; !exists {
+		return nil, fmt.Errorf("%w: missing %s rule set", ErrInvalidScoring, FullRules)
+	}
+
+	managers := make(map[string]*ScoreCalculatorManager)
+	for name, rs := range cfg.RuleSets {
+		scm, err := newRuleSetManager(cfg.Version, rs, aggregator, protocolAddresses)
+		if err != nil {
+			return nil, fmt.Errorf("%w: rule set %s: %s", ErrInvalidScoring, name, err)
+		}
+		managers[name] = scm
+	}
+
+	return &ScoringRules{
+		managers: managers,
+		Version:  cfg.Version,
+	}, nil
+}
+
+// Check scoring configuration can be built and provides every rule set used by seasons
+func ValidateScoring(cfg *config.Scoring, seasons []Season) error {
+	rules, err := NewScoringRules(cfg, nil)
+	if err != nil {
+		return err
+	}
+	for _, s := range seasons {
+		if _, err := rules.Manager(s.Rules); err != nil {
+			return fmt.Errorf("%w: season %s uses rule set %q", err, s.Name, s.Rules)
+		}
+	}
+	return nil
+}
+
+// Get score calculator manager for rule set. Empty name means full rule set
+func (r *ScoringRules) Manager(rules string) (*ScoreCalculatorManager, error) {
+	if rules == "" {
+		rules = FullRules
+	}
+	scm, exists := r.managers[rules]
+	if !exists {
+		return nil, ErrUnknownRules
+	}
+	return scm, nil
+}
+
+// Get score calculator manager matching rule set with default scoring configuration
+func ScoreCalculatorManagerForRules(rules string, aggregator BuyValueAggregator) (*ScoreCalculatorManager, error) {
+	return DefaultScoringRules(aggregator).Manager(rules)
+}
+
+func newRuleSetManager(version string, rs config.RuleSet, aggregator BuyValueAggregator, protocolAddresses []string) (*ScoreCalculatorManager, error) {
+	stacking, err := StackingPolicyFromString(rs.Stacking)
+	if err != nil {
+		return nil, err
+	}
+	if rs.Cap != 0 && rs.Cap < 100 {
+		return nil, fmt.Errorf("boost cap %d would lower points", rs.Cap)
+	}
+
+	opts := []ScoreCalculatorManagerFunc{WithVersion(version), WithStacking(stacking, rs.Cap)}
+	for _, b := range rs.Builders {
+		opt, err := builderFromConfig(b, protocolAddresses)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, opt)
+	}
+
+	for _, b := range rs.Boosters {
+		booster, err := boosterFromConfig(b, aggregator)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBoosters(booster))
+	}
+
+	return NewScoreCalculatorManager(opts...), nil
+}
+
+// Register builder in manager according to how it computes score
+func builderFromConfig(b config.Builder, protocolAddresses []string) (ScoreCalculatorManagerFunc, error) {
+	switch RuleName(b.Rule) {
+	case AmountFundRuleName:
+		return WithBuilders(&AmountFundedScoreCalculator{}), nil
+	case NumberOfProjectsRuleName:
+		return WithBuilders(&NumberOfProjectsScoreCalculator{Points: b.Points}), nil
+	case ResalerRuleName:
+		return WithBuilders(&ResalerScoreCalculator{}), nil
+	case OffseterRuleName:
+		return WithBuilders(&OffseterScoreCalculator{Multiplier: b.Multiplier}), nil
+	case EarlyAdopterRuleName:
+		return WithBuilders(&EarlyAdopterScoreCalculator{PointsPerProject: b.Projects}), nil
+	case HoldingRuleName:
+		return WithHistoryBuilders(&HoldingScoreCalculator{Points: b.Points}), nil
+	case TransferClawbackRuleName:
+		excluded := append(append([]string{}, protocolAddresses...), b.Exclude...)
+		return WithHistoryBuilders(&TransferClawbackScoreCalculator{Excluded: excluded}), nil
+	case StakingRuleName:
+		return WithHistoryBuilders(&StakingScoreCalculator{Rates: b.Rates}), nil
+	case ReferralRuleName:
+		return WithHistoryBuilders(&ReferralScoreCalculator{Share: b.Multiplier}), nil
+	case GrantRuleName:
+		return WithBuilders(&GrantScoreCalculator{}), nil
+	case FarmingDepositRuleName:
+		return WithBuilders(&FarmingDepositScoreCalculator{Multiplier: b.Multiplier}), nil
+	case FarmingWithdrawRuleName:
+		return WithBuilders(&FarmingWithdrawScoreCalculator{Multiplier: b.Multiplier}), nil
+	}
+	return nil, fmt.Errorf("unknown rule %q", b.Rule)
+}
+
+func boosterFromConfig(b config.Booster, aggregator BuyValueAggregator) (BoostCalculator, error) {
+	if len(b.Tiers) == 0 {
+		return nil, fmt.Errorf("boost %q has no tiers", b.Boost)
+	}
+	steps := make([]boostValueItem, len(b.Tiers))
+	for i, t := range b.Tiers {
+		if t.Coef == 0 {
+			return nil, fmt.Errorf("boost %q has a tier without coef", b.Boost)
+		}
+		steps[i] = boostValueItem{step: t.Step, coef: t.Coef}
+	}
+
+	switch b.Boost {
+	case ProjectValueBoostName:
+		// NOTE: GetInterval expects tiers from the highest step to the lowest one
+		for i := 1; i < len(steps); i++ {
+			if steps[i].step >= steps[i-1].step {
+				return nil, fmt.Errorf("boost %q tiers must be sorted by descending step", b.Boost)
+			}
+		}
+		return &ProjectValueBoostCalculator{Steps: steps}, nil
+	case FundingMilestoneBoostName:
+		if b.Project == "" {
+			return nil, fmt.Errorf("boost %q requires a project", b.Boost)
+		}
+		for i := 1; i < len(steps); i++ {
+			if steps[i].step <= steps[i-1].step {
+				return nil, fmt.Errorf("boost %q tiers must be sorted by ascending step", b.Boost)
+			}
+		}
+		return NewFundingMilestoneBoostCalculator(aggregator, b.Project, b.Slot, steps), nil
+	}
+	return nil, fmt.Errorf("unknown boost %q", b.Boost)
+}
+
+// Get boosters of given type that apply to event
+func MatchingBoosters[T BoostCalculator](scm *ScoreCalculatorManager, e DomainEvent) []T {
+	var res []T
+	for _, b := range scm.booster {
+		if t, ok := b.(T); ok && b.Check(e) != nil {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// Find first booster of given type registered in manager
+func FindBooster[T BoostCalculator](scm *ScoreCalculatorManager) (T, bool) {
+	for _, b := range scm.booster {
+		if t, ok := b.(T); ok {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,155 @@
+package leaderboard_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ScoringRules", func() {
+	It("should load network scoring files", func() {
+		for _, network := range []string{"sepolia", "goerli", "mainnet"} {
+			scoring, err := config.ScoringFromYamlFile("../../scoring." + network + ".yaml")
+			Expect(err).To(BeNil())
+			Expect(leaderboard.ValidateScoring(scoring, []leaderboard.Season{})).To(BeNil())
+		}
+	})
+
+	It("should refuse unknown keys in scoring files", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "scoring.yaml")
+		Expect(os.WriteFile(file, []byte("version: test\nrule_sets:\n  full:\n    builders:\n      - rule: offseter\n        multiplyer: 0\n"), 0o600)).To(Succeed())
+
+		_, err := config.ScoringFromYamlFile(file)
+		Expect(err).To(MatchError(config.ErrUnmarshalFailed))
+		Expect(err.Error()).To(ContainSubstring("multiplyer"))
+
+		_, err = config.ScoringFromYamlFile(filepath.Join(GinkgoT().TempDir(), "missing.yaml"))
+		Expect(err).To(MatchError(config.ErrParseConfig))
+	})
+
+	It("should compute same score as default rules", func() {
+		scoring, err := config.ScoringFromYamlFile("../../scoring.mainnet.yaml")
+		Expect(err).To(BeNil())
+		rules, err := leaderboard.NewScoringRules(scoring, newGivenValueMinterValueAggregator(74109))
+		Expect(err).To(BeNil())
+		scm, err := rules.Manager(leaderboard.FullRules)
+		Expect(err).To(BeNil())
+
+		buy := buyProjectEvt("Karathuru", 11000*1000000)
+		line := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{buy}).ComputeScore(scm)
+		defaultLine := leaderboard.NewPersonnalRanking("aBeautifulWallet", []leaderboard.DomainEvent{buy}).ComputeScore(leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(74109)))
+
+		Expect(line.TotalScore).To(Equal(defaultLine.TotalScore))
+		Expect(line.RulesVersion).To(Equal(scoring.Version))
+		Expect(defaultLine.RulesVersion).To(Equal(leaderboard.DefaultRulesVersion))
+	})
+
+	It("should use configured parameters", func() {
+		scoring := &config.Scoring{
+			Version: "test",
+			RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {
+					Builders: []config.Builder{
+						{Rule: string(leaderboard.OffseterRuleName), Multiplier: setting(200)},
+						{Rule: string(leaderboard.EarlyAdopterRuleName), Projects: map[string]uint64{"Karathuru": 10}},
+					},
+				},
+			},
+		}
+		rules, err := leaderboard.NewScoringRules(scoring, nil)
+		Expect(err).To(BeNil())
+		scm, _ := rules.Manager("")
+
+		score := scm.ComputeScore(offsetHundredEvt(), []leaderboard.Score{})
+		Expect(len(score)).To(Equal(1))
+		Expect(score[0].Points.String()).To(Equal("20000"))
+
+		score = scm.ComputeScore(buyProjectEvt("Karathuru", 100), []leaderboard.Score{})
+		Expect(len(score)).To(Equal(1))
+		Expect(score[0].Points.String()).To(Equal("10000000"))
+	})
+
+	It("should keep explicit zero settings", func() {
+		scoring := &config.Scoring{
+			Version: "test",
+			RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {
+					Builders: []config.Builder{
+						{Rule: string(leaderboard.OffseterRuleName), Multiplier: setting(0)},
+					},
+				},
+			},
+		}
+		rules, err := leaderboard.NewScoringRules(scoring, nil)
+		Expect(err).To(BeNil())
+		scm, _ := rules.Manager("")
+
+		score := scm.ComputeScore(offsetHundredEvt(), []leaderboard.Score{})
+		Expect(len(score)).To(Equal(1))
+		Expect(score[0].Points.String()).To(Equal("0"))
+	})
+
+	Context("invalid configuration", func() {
+		It("should require a version", func() {
+			scoring := leaderboard.DefaultScoringConfig()
+			scoring.Version = ""
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should require full rule set", func() {
+			scoring := &config.Scoring{Version: "test", RuleSets: map[string]config.RuleSet{}}
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should reject unknown rules and boosts", func() {
+			scoring := &config.Scoring{Version: "test", RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {Builders: []config.Builder{{Rule: "not_a_rule"}}},
+			}}
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+
+			scoring.RuleSets[leaderboard.FullRules] = config.RuleSet{Boosters: []config.Booster{{Boost: "not_a_boost", Tiers: []config.Tier{{Step: 1, Coef: 100}}}}}
+			_, err = leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should reject unsorted tiers", func() {
+			scoring := &config.Scoring{Version: "test", RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {Boosters: []config.Booster{{Boost: leaderboard.ProjectValueBoostName, Tiers: []config.Tier{{Step: 500, Coef: 150}, {Step: 1000, Coef: 200}}}}},
+			}}
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should require a project for funding milestones", func() {
+			scoring := &config.Scoring{Version: "test", RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {Boosters: []config.Booster{{Boost: leaderboard.FundingMilestoneBoostName, Tiers: []config.Tier{{Step: 500, Coef: 150}}}}},
+			}}
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should reject unknown stacking policy and lowering cap", func() {
+			scoring := &config.Scoring{Version: "test", RuleSets: map[string]config.RuleSet{
+				leaderboard.FullRules: {Stacking: "not_a_policy"},
+			}}
+			_, err := leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+
+			scoring.RuleSets[leaderboard.FullRules] = config.RuleSet{Cap: 50}
+			_, err = leaderboard.NewScoringRules(scoring, nil)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidScoring))
+		})
+
+		It("should reject seasons with unknown rule set", func() {
+			err := leaderboard.ValidateScoring(leaderboard.DefaultScoringConfig(), []leaderboard.Season{{Name: "Season 1", Rules: "unknown"}})
+			Expect(err).To(MatchError(leaderboard.ErrUnknownRules))
+		})
+	})
+})
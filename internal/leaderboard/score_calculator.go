@@ -1,6 +1,8 @@
 package leaderboard
 
 import (
+	"time"
+
 	"github.com/charmbracelet/log"
 	u256 "github.com/holiman/uint256"
 )
@@ -80,6 +82,37 @@ func (s *AdditionScore) Aggregate(n ScoreCalculator) ScoreCalculator {
 	return s
 }
 
+// Removes value from inner points, saturates at zero as points are unsigned
+type SubtractionScore struct {
+	inner ScoreCalculator
+	value *u256.Int
+}
+
+func NewSubtractionScore(value *u256.Int, inner ScoreCalculator) ScoreCalculator {
+	return &SubtractionScore{
+		inner,
+		value,
+	}
+}
+
+func (s *SubtractionScore) GetPoints() *u256.Int {
+	if s.inner == nil {
+		return u256.NewInt(0)
+	}
+
+	points := s.inner.GetPoints()
+	if points.Cmp(s.value) <= 0 {
+		return u256.NewInt(0)
+	}
+	var res u256.Int
+	return res.Sub(points, s.value)
+}
+
+func (s *SubtractionScore) Aggregate(n ScoreCalculator) ScoreCalculator {
+	s.inner = n
+	return s
+}
+
 type BoostScore struct {
 	inner  ScoreCalculator
 	factor *u256.Int
@@ -113,20 +146,31 @@ func (s *BoostScore) Aggregate(n ScoreCalculator) ScoreCalculator {
 type (
 	ScoreCalculatorManagerFunc func(*ScoreCalculatorManagerOpts)
 	ScoreCalculatorManagerOpts struct {
+		version    string
+		stacking   StackingPolicy
+		boostCap   uint64
 		builder    []ScoreBuilder
+		history    []HistoryScoreBuilder
 		calculator []ScoreCalculator
 		booster    []BoostCalculator
 	}
 
 	ScoreCalculatorManager struct {
+		version    string
+		stacking   StackingPolicy
+		boostCap   uint64
 		builder    []ScoreBuilder
+		history    []HistoryScoreBuilder
 		calculator []ScoreCalculator
 		booster    []BoostCalculator
+		// Records how scores are computed when explaining a score
+		trace *ScoreTrace
 	}
 )
 
 func defaultScoreCalculatorManagerOpts() *ScoreCalculatorManagerOpts {
 	return &ScoreCalculatorManagerOpts{
+		stacking:   MultiplicativeStacking,
 		builder:    []ScoreBuilder{},
 		calculator: []ScoreCalculator{},
 		booster:    []BoostCalculator{},
@@ -141,7 +185,11 @@ func NewScoreCalculatorManager(opts ...ScoreCalculatorManagerFunc) *ScoreCalcula
 	}
 
 	return &ScoreCalculatorManager{
+		version:    opt.version,
+		stacking:   opt.stacking,
+		boostCap:   opt.boostCap,
 		builder:    opt.builder,
+		history:    opt.history,
 		calculator: opt.calculator,
 		booster:    opt.booster,
 	}
@@ -153,57 +201,150 @@ func WithBuilders(builders ...ScoreBuilder) ScoreCalculatorManagerFunc {
 	}
 }
 
+func WithHistoryBuilders(builders ...HistoryScoreBuilder) ScoreCalculatorManagerFunc {
+	return func(opt *ScoreCalculatorManagerOpts) {
+		opt.history = append(opt.history, builders...)
+	}
+}
+
 func WithBoosters(boosters ...BoostCalculator) ScoreCalculatorManagerFunc {
 	return func(opt *ScoreCalculatorManagerOpts) {
 		opt.booster = append(opt.booster, boosters...)
 	}
 }
 
+// Version of the scoring rules recorded on each computed line
+func WithVersion(version string) ScoreCalculatorManagerFunc {
+	return func(opt *ScoreCalculatorManagerOpts) {
+		opt.version = version
+	}
+}
+
+// How boosts are combined. capCoef is the maximum overall coef in percent, 0 means no cap
+func WithStacking(policy StackingPolicy, capCoef uint64) ScoreCalculatorManagerFunc {
+	return func(opt *ScoreCalculatorManagerOpts) {
+		opt.stacking = policy
+		opt.boostCap = capCoef
+	}
+}
+
 func FullScoreCalculatorManager(aggregator BuyValueAggregator) *ScoreCalculatorManager {
-	return NewScoreCalculatorManager(
-		WithBuilders(
-			&AmountFundedScoreCalculator{},
-			&NumberOfProjectsScoreCalculator{},
-			&ResalerScoreCalculator{},
-			&OffseterScoreCalculator{},
-			&EarlyAdopterScoreCalculator{},
-		),
-		WithBoosters(
-			NewKaratFundingMilestoneBoostCalculator(aggregator),
-			DefaultProjectValueBoostCalculator(),
-		),
-	)
+	scm, _ := DefaultScoringRules(aggregator).Manager(FullRules)
+	return scm
 }
 
 func MintPageCalculatorManager() *ScoreCalculatorManager {
-	return NewScoreCalculatorManager(WithBuilders(&AmountFundedScoreCalculator{}), WithBoosters(DefaultProjectValueBoostCalculator()))
+	scm, _ := DefaultScoringRules(nil).Manager(MintPageRules)
+	return scm
+}
+
+func (scm ScoreCalculatorManager) Version() string {
+	return scm.version
 }
 
 func (scm ScoreCalculatorManager) ComputeScore(evt DomainEvent, score []Score) []Score {
+	var et *EventTrace
+	if scm.trace != nil {
+		et = scm.trace.event(evt)
+	}
 	for _, c := range scm.builder {
 		if c.Supports(evt, score) {
 			s := c.Compute(evt, score)
 			if nil != s {
-				for _, c := range scm.booster {
-					b := c.Check(evt)
-					if nil != b {
-						c.Apply(evt, b, s)
-					}
+				s.Wallet = evt.WalletAddress
+				boosts := scm.boost(evt, s)
+				if et != nil {
+					bt := appliedTrace(componentName(c, "ScoreCalculator"), s)
+					bt.Boosts = boosts
+					et.Builders = append(et.Builders, bt)
 				}
 				score = append(score, *s)
+			} else if et != nil {
+				et.Builders = append(et.Builders, BuilderTrace{Builder: componentName(c, "ScoreCalculator"), Status: BuilderNoScore, Reason: defaultEmptyReason})
+			}
+		} else if et != nil {
+			et.Builders = append(et.Builders, BuilderTrace{Builder: componentName(c, "ScoreCalculator"), Status: BuilderSkipped, Reason: skipReason(c, evt, score)})
+		}
+	}
+	return score
+}
+
+// Append scores computed by history builders over [from, until]
+func (scm ScoreCalculatorManager) ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time, score []Score) []Score {
+	for _, h := range scm.history {
+		var computed []Score
+		if sh, ok := h.(ScoredHistoryScoreBuilder); ok {
+			computed = sh.ComputeScoredHistory(wallet, events, from, until, score)
+		} else {
+			computed = h.ComputeHistory(wallet, events, from, until)
+		}
+		for _, s := range computed {
+			s.Wallet = wallet
+			boosts := scm.boost(s.Event, &s)
+			if scm.trace != nil {
+				bt := appliedTrace(componentName(h, "ScoreCalculator"), &s)
+				bt.Boosts = boosts
+				scm.trace.History = append(scm.trace.History, bt)
 			}
+			score = append(score, s)
 		}
 	}
 	return score
 }
 
+// Multiplicative boosters are applied sequentially like historical computation, a booster sees points
+// boosted by the previous ones. Other policies apply every booster on base points so that order does not matter.
+// Resulting coefs are then combined according to stacking policy.
+// Boosters that were checked are only returned when explaining a score
+func (scm ScoreCalculatorManager) boost(evt DomainEvent, s *Score) []BoosterTrace {
+	var base u256.Int
+	base.Set(s.Points)
+
+	var boosts []Boost
+	var traces []BoosterTrace
+	var current u256.Int
+	current.Set(&base)
+	for _, c := range scm.booster {
+		b := c.Check(evt)
+		if nil == b {
+			continue
+		}
+		var points u256.Int
+		points.Set(&current)
+		res := c.Apply(evt, b, &Score{Points: &points, Rule: s.Rule, Event: evt})
+		if nil != res {
+			boosts = append(boosts, res.Boosts...)
+			if scm.stacking == MultiplicativeStacking {
+				current.Set(res.Points)
+			}
+		}
+		if scm.trace != nil {
+			traces = append(traces, boosterTrace(c, evt, s, res))
+		}
+	}
+
+	s.Base = &base
+	s.Boosts = boosts
+	s.Stacking = scm.stacking
+	s.Points, s.Capped = scm.stacking.Stack(&base, boosts, scm.boostCap)
+	return traces
+}
+
 // Compute total score based on all scores item
+// Negative scores are subtracted once every point has been added, total never goes below zero
 // Divides by 10^6 to avoid loosing precision
 func TotalScore(score []Score) *u256.Int {
 	var sc ScoreCalculator
 	sc = &baseScoreCalculator{}
 	for _, s := range score {
-		sc = NewAdditionScore(s.Points, sc)
+		if !s.Negative {
+			sc = NewAdditionScore(s.Points, sc)
+		}
+	}
+	for _, s := range score {
+		if s.Negative {
+			sc = NewSubtractionScore(s.Points, sc)
+		}
 	}
 
 	var total u256.Int
@@ -218,6 +359,13 @@ func AggregateCategories(scores []Score) *CategorisedScore {
 		EarlyAdopterRuleName:     FundCategory,
 		OffseterRuleName:         FarmingCategory,
 		ResalerRuleName:          FarmingCategory,
+		HoldingRuleName:          FundCategory,
+		TransferClawbackRuleName: FundCategory,
+		FarmingDepositRuleName:   FarmingCategory,
+		FarmingWithdrawRuleName:  FarmingCategory,
+		StakingRuleName:          FarmingCategory,
+		ReferralRuleName:         OtherCategory,
+		GrantRuleName:            OtherCategory,
 	}
 	categorisedEvents := map[string][]Score{
 		FundCategory:    {},
@@ -257,8 +405,28 @@ func (sc *AmountFundedScoreCalculator) Compute(e DomainEvent, score []Score) *Sc
 	return &Score{Points: value, Event: e, Rule: AmountFundRuleName}
 }
 
+const (
+	defaultPointsPerProject   uint64 = 200
+	defaultOffseterMultiplier uint64 = 100
+)
+
+// Rule setting, nil falls back to default while an explicit 0 is kept
+func settingOr(setting *uint64, def uint64) uint64 {
+	if setting == nil {
+		return def
+	}
+	return *setting
+}
+
 // Number of projects - Calculate points based on number of projects
-type NumberOfProjectsScoreCalculator struct{}
+type NumberOfProjectsScoreCalculator struct {
+	// Points given for each new project, defaults to 200
+	Points *uint64
+}
+
+func (sc *NumberOfProjectsScoreCalculator) pointsPerProject() uint64 {
+	return settingOr(sc.Points, defaultPointsPerProject)
+}
 
 func (sc *NumberOfProjectsScoreCalculator) Supports(e DomainEvent, score []Score) bool {
 	pName, exists := e.Metadata["project_name"]
@@ -305,7 +473,9 @@ func (sc *NumberOfProjectsScoreCalculator) Compute(e DomainEvent, score []Score)
 			}
 			// NOTE: Multiply by 10^6 to avoid loosing precision
 			// Each project = 200 points * 10^6
-			return &Score{Points: u256.NewInt(200000000), Event: e, Rule: NumberOfProjectsRuleName}
+			var points u256.Int
+			points.Mul(u256.NewInt(sc.pointsPerProject()), u256.NewInt(1000000))
+			return &Score{Points: &points, Event: e, Rule: NumberOfProjectsRuleName}
 		}
 	}
 
@@ -336,7 +506,10 @@ func (sc *ResalerScoreCalculator) Compute(e DomainEvent, score []Score) *Score {
 	return &Score{Points: p, Event: e, Rule: ResalerRuleName}
 }
 
-type OffseterScoreCalculator struct{}
+type OffseterScoreCalculator struct {
+	// Points per tCO2 offset, defaults to 100
+	Multiplier *uint64
+}
 
 func (sc *OffseterScoreCalculator) Supports(e DomainEvent, score []Score) bool {
 	return e.EventName == "offseter:claim"
@@ -353,9 +526,11 @@ func (sc *OffseterScoreCalculator) Compute(e DomainEvent, score []Score) *Score
 		return nil
 	}
 
+	multiplier := settingOr(sc.Multiplier, defaultOffseterMultiplier)
+
 	var points u256.Int
 	// NOTE: 100 for base multiplier 1000000 to avoid loosing precision
-	points.Mul(p, u256.NewInt(100))
+	points.Mul(p, u256.NewInt(multiplier))
 
 	return &Score{Points: &points, Event: e, Rule: OffseterRuleName}
 }
@@ -366,14 +541,24 @@ var pointsPerProject = map[string]uint64{
 	"Manjarisoa":   100,
 }
 
-type EarlyAdopterScoreCalculator struct{}
+type EarlyAdopterScoreCalculator struct {
+	// Points given to early adopters of each project, defaults to pointsPerProject
+	PointsPerProject map[string]uint64
+}
+
+func (sc *EarlyAdopterScoreCalculator) projects() map[string]uint64 {
+	if sc.PointsPerProject == nil {
+		return pointsPerProject
+	}
+	return sc.PointsPerProject
+}
 
 func (sc *EarlyAdopterScoreCalculator) Supports(e DomainEvent, score []Score) bool {
 	projectName, exists := e.Metadata["project_name"]
 	if !exists {
 		return false
 	}
-	_, exists = pointsPerProject[projectName]
+	_, exists = sc.projects()[projectName]
 	return exists
 }
 
@@ -389,7 +574,7 @@ func (sc *EarlyAdopterScoreCalculator) Compute(e DomainEvent, score []Score) *Sc
 	}
 
 	defaultScore := uint64(50)
-	p, exists := pointsPerProject[projectName]
+	p, exists := sc.projects()[projectName]
 	if exists {
 		defaultScore = p
 	}
@@ -0,0 +1,121 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/charmbracelet/log"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+// Season is a time-boxed leaderboard computed from events recorded inside [StartAt, EndAt).
+// Once the season is over, its results are computed one last time and frozen.
+type Season struct {
+	StartAt  time.Time
+	EndAt    time.Time
+	FrozenAt *time.Time
+	Name     string `gorm:"unique"`
+	Rules    string
+	ID       ulid.ULID `gorm:"primaryKey"`
+}
+
+type SeasonLeaderboardLine struct {
+	LeaderboardLine
+	SeasonID ulid.ULID `gorm:"index"`
+}
+
+func SeasonFromConfig(s config.Season) Season {
+	rules := s.Rules
+	if rules == "" {
+		rules = FullRules
+	}
+	return Season{
+		StartAt: s.StartAt,
+		EndAt:   s.EndAt,
+		Name:    s.Name,
+		Rules:   rules,
+		ID:      ulid.Make(),
+	}
+}
+
+func SeasonsFromConfig(seasons []config.Season) []Season {
+	var res []Season
+	for _, s := range seasons {
+		res = append(res, SeasonFromConfig(s))
+	}
+	return res
+}
+
+func (s *Season) IsFrozen() bool {
+	return s.FrozenAt != nil
+}
+
+// Season has started and its results can still change
+func (s *Season) IsActive(now time.Time) bool {
+	return !s.IsFrozen() && !now.Before(s.StartAt)
+}
+
+func (s *Season) IsClosed(now time.Time) bool {
+	return !now.Before(s.EndAt)
+}
+
+func (s *Season) Contains(t time.Time) bool {
+	return !t.Before(s.StartAt) && t.Before(s.EndAt)
+}
+
+// Keep only events recorded inside season window
+func (s *Season) Events(events []DomainEvent) []DomainEvent {
+	var res []DomainEvent
+	for _, e := range events {
+		if s.Contains(e.RecordedAt) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func SeasonLeaderboardLineFromLine(seasonID ulid.ULID, line *LeaderboardLine) SeasonLeaderboardLine {
+	return SeasonLeaderboardLine{
+		LeaderboardLine: *line,
+		SeasonID:        seasonID,
+	}
+}
+
+// Upsert configured seasons. Frozen seasons are left untouched as their results are final.
+func SyncSeasons(db *gorm.DB, seasons []Season) error {
+	for _, s := range seasons {
+		var existing Season
+		err := db.Where("name = ?", s.Name).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := db.Create(&s).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if existing.IsFrozen() {
+			log.Info("season is frozen, skipping configuration update", "season", existing.Name)
+			continue
+		}
+
+		err = db.Model(&existing).Updates(map[string]interface{}{
+			"start_at": s.StartAt,
+			"end_at":   s.EndAt,
+			"rules":    s.Rules,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSeasons(db *gorm.DB) ([]Season, error) {
+	var seasons []Season
+	err := db.Order("start_at DESC").Find(&seasons).Error
+	return seasons, err
+}
@@ -0,0 +1,68 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/config"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Season", func() {
+	var season leaderboard.Season
+	BeforeEach(func() {
+		season = leaderboard.SeasonFromConfig(config.Season{
+			Name:    "Season 1",
+			StartAt: time.Unix(1703845700, 0),
+			EndAt:   time.Unix(1703845800, 0),
+		})
+	})
+
+	It("should default to full rule set", func() {
+		Expect(season.Rules).To(Equal(leaderboard.FullRules))
+	})
+
+	It("should keep only events inside its window", func() {
+		events := getTestEvents([]leaderboard.DomainEvent{})
+		// 3 events at 1703845777, 1 at 1703845960
+		Expect(len(season.Events(events))).To(Equal(3))
+	})
+
+	It("should exclude end of window", func() {
+		Expect(season.Contains(season.StartAt)).To(BeTrue())
+		Expect(season.Contains(season.EndAt)).To(BeFalse())
+	})
+
+	It("should be active once started and until frozen", func() {
+		Expect(season.IsActive(time.Unix(1703845600, 0))).To(BeFalse())
+		Expect(season.IsActive(time.Unix(1703845750, 0))).To(BeTrue())
+		Expect(season.IsActive(time.Unix(1703845900, 0))).To(BeTrue())
+		Expect(season.IsClosed(time.Unix(1703845900, 0))).To(BeTrue())
+
+		frozenAt := time.Unix(1703845900, 0)
+		season.FrozenAt = &frozenAt
+		Expect(season.IsActive(time.Unix(1703845950, 0))).To(BeFalse())
+	})
+
+	It("should compute ranking from season events only", func() {
+		events := getTestEvents([]leaderboard.DomainEvent{})
+		events = append(events, buyProjectEvt("Banegas Farm", 100*1000000))
+		late := buyProjectEvt("Las Delicias", 100*1000000)
+		late.RecordedAt = time.Unix(1703845900, 0)
+		events = append(events, late)
+
+		pr := leaderboard.NewPersonnalRanking("0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2", season.Events(events))
+		scm, err := leaderboard.ScoreCalculatorManagerForRules(season.Rules, newGivenValueMinterValueAggregator(50000))
+		Expect(err).To(BeNil())
+		line := pr.ComputeScore(scm)
+
+		// 100 amount funded + 200 early adopter + 200 number of projects for Banegas Farm only
+		Expect(line.TotalScore).To(Equal("500"))
+	})
+
+	It("should reject unknown rule set", func() {
+		_, err := leaderboard.ScoreCalculatorManagerForRules("unknown", newGivenValueMinterValueAggregator(50000))
+		Expect(err).To(Equal(leaderboard.ErrUnknownRules))
+	})
+})
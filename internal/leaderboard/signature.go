@@ -0,0 +1,99 @@
+package leaderboard
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Longest validity accepted for a signed message, used nonces only need to be kept that long
+const maxSignatureValidity = 24 * time.Hour
+
+var (
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrInvalidNonce      = errors.New("nonce must be a felt")
+	ErrSignatureExpired  = errors.New("signature has expired")
+	ErrExpiryTooFar      = errors.New("signature expiry is too far in the future")
+	ErrSignatureReplayed = errors.New("signature nonce has already been used")
+)
+
+// Nonce of a signed message, stored once used so that a signature cannot be replayed
+type SignatureNonce struct {
+	CreatedAt     time.Time
+	ExpiresAt     time.Time `gorm:"index"`
+	WalletAddress string    `gorm:"uniqueIndex:idx_signature_nonce"`
+	Nonce         string    `gorm:"uniqueIndex:idx_signature_nonce"`
+	ID            ulid.ULID `gorm:"primaryKey"`
+}
+
+// Typed data domain wallets sign leaderboard messages for
+func SigningDomain(network starknet.StarknetNetwork) starknet.TypedDataDomain {
+	return starknet.TypedDataDomain{Name: "Carbonable Leaderboard", Version: "1", ChainId: starknet.ChainIdFromNetwork(network)}
+}
+
+// Nonce chosen by wallet for a signed message
+func ParseNonce(nonce string) (*felt.Felt, error) {
+	f := starknet.FeltFromString(nonce)
+	if f == nil {
+		return nil, ErrInvalidNonce
+	}
+	return f, nil
+}
+
+// Signed messages expire at given unix timestamp, at most maxSignatureValidity after now
+func CheckSignatureExpiry(expiry uint64, now time.Time) error {
+	at := time.Unix(int64(expiry), 0)
+	if !at.After(now) {
+		return ErrSignatureExpired
+	}
+	if at.After(now.Add(maxSignatureValidity)) {
+		return ErrExpiryTooFar
+	}
+	return nil
+}
+
+// Consume nonce of a verified message, a nonce can only be used once per wallet
+func UseSignatureNonce(db *gorm.DB, wallet string, nonce *felt.Felt, expiry uint64, now time.Time) error {
+	if err := CheckSignatureExpiry(expiry, now); err != nil {
+		return err
+	}
+	n := SignatureNonce{CreatedAt: now, ExpiresAt: time.Unix(int64(expiry), 0), WalletAddress: starknet.NormalizeAddress(wallet).String(), Nonce: nonce.String(), ID: ulid.Make()}
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&n)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrSignatureReplayed
+	}
+	return nil
+}
+
+// Check wallet signed message with its account
+func VerifySignature(rpc starknet.StarknetRpcClient, domain starknet.TypedDataDomain, wallet string, message starknet.TypedMessage, signature []string) error {
+	hash, err := starknet.TypedDataHash(domain, wallet, message)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var sig []felt.Felt
+	for _, s := range signature {
+		f := starknet.FeltFromString(s)
+		if f == nil {
+			return ErrInvalidSignature
+		}
+		sig = append(sig, *f)
+	}
+
+	valid, err := starknet.IsValidSignature(rpc, wallet, hash, sig)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Signed messages", func() {
+	now := time.Unix(t0, 0)
+
+	It("should refuse expired signatures", func() {
+		Expect(leaderboard.CheckSignatureExpiry(uint64(t0), now)).To(MatchError(leaderboard.ErrSignatureExpired))
+		Expect(leaderboard.CheckSignatureExpiry(uint64(t0-day), now)).To(MatchError(leaderboard.ErrSignatureExpired))
+	})
+
+	It("should refuse expiry more than a day ahead", func() {
+		Expect(leaderboard.CheckSignatureExpiry(uint64(t0+day), now)).To(BeNil())
+		Expect(leaderboard.CheckSignatureExpiry(uint64(t0+day+1), now)).To(MatchError(leaderboard.ErrExpiryTooFar))
+	})
+
+	It("should sign nonce and expiry along with referral code", func() {
+		first, err := leaderboard.ParseNonce("0x1")
+		Expect(err).To(BeNil())
+		second, _ := leaderboard.ParseNonce("0x2")
+
+		claim := leaderboard.ReferralClaimMessage("carbon", first, uint64(t0))
+		Expect(claim.Hash()).NotTo(Equal(leaderboard.ReferralClaimMessage("carbon", second, uint64(t0)).Hash()))
+		Expect(claim.Hash()).NotTo(Equal(leaderboard.ReferralClaimMessage("carbon", first, uint64(t0+1)).Hash()))
+	})
+
+	It("should refuse nonces that are not felts", func() {
+		_, err := leaderboard.ParseNonce("not a felt")
+		Expect(err).To(MatchError(leaderboard.ErrInvalidNonce))
+	})
+})
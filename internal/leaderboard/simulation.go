@@ -0,0 +1,216 @@
+package leaderboard
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	u256 "github.com/holiman/uint256"
+	"gorm.io/gorm"
+)
+
+const (
+	SimulateBuy         string = "buy"
+	SimulateClaimYield  string = "claim_yield"
+	SimulateClaimOffset string = "claim_offset"
+	SimulateStake       string = "stake"
+	SimulateUnstake     string = "unstake"
+	// Simulated actions are capped to keep simulation cheap
+	MaxSimulatedActions int = 50
+)
+
+var (
+	ErrInvalidSimulatedAction = errors.New("invalid simulated action")
+	ErrTooManySimulatedAction = fmt.Errorf("at most %d actions can be simulated", MaxSimulatedActions)
+)
+
+// Hypothetical action of wallet, amount is in $ for buys, yield claims and stakes and in tCO2 for offset claims
+type SimulatedAction struct {
+	Kind    string
+	Project string
+	// Hex felt, as stored in event metadata. Optional
+	Slot   string
+	Amount string
+	// Contract staked into, yielder or offseter. Defaults to yielder
+	Contract string
+}
+
+// Score of wallet before and after simulated actions, ranks are 0 for wallets that are not ranked
+type Simulation struct {
+	Current       *LeaderboardLine
+	Simulated     *LeaderboardLine
+	CurrentRank   int
+	ProjectedRank int
+	// Other wallets are ranked with their current score, with a horizon they would accrue too
+	// and projected rank is the best rank wallet can expect
+	ProjectedRankIsLowerBound bool
+}
+
+// Positive when wallet climbs in ranking
+func (s *Simulation) RankChange() int {
+	if s.CurrentRank == 0 || s.ProjectedRank == 0 {
+		return 0
+	}
+	return s.CurrentRank - s.ProjectedRank
+}
+
+// Parses a decimal amount into 10^6 units, inverse of FormatAmount
+func ParseAmount(s string) (*u256.Int, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if whole == "" || len(fraction) > 6 {
+		return nil, ErrInvalidSimulatedAction
+	}
+	w, err := u256.FromDecimal(whole)
+	if err != nil {
+		return nil, ErrInvalidSimulatedAction
+	}
+	f := u256.NewInt(0)
+	if fraction != "" {
+		f, err = u256.FromDecimal(fraction + strings.Repeat("0", 6-len(fraction)))
+		if err != nil {
+			return nil, ErrInvalidSimulatedAction
+		}
+	}
+	var res u256.Int
+	res.Mul(w, u256.NewInt(1000000))
+	res.Add(&res, f)
+	if res.IsZero() {
+		return nil, ErrInvalidSimulatedAction
+	}
+	return &res, nil
+}
+
+// Domain event the action would emit, recorded at given time
+func (a SimulatedAction) Event(wallet string, i int, at time.Time) (DomainEvent, error) {
+	if a.Project == "" {
+		return DomainEvent{}, ErrInvalidSimulatedAction
+	}
+	amount, err := ParseAmount(a.Amount)
+	if err != nil {
+		return DomainEvent{}, err
+	}
+	e := DomainEvent{
+		RecordedAt:    at,
+		EventId:       fmt.Sprintf("simulation#%d", i),
+		WalletAddress: wallet,
+		Source:        "simulation",
+		Data:          EventData{"address": wallet},
+		Metadata:      EventMetadata{"project_name": a.Project},
+	}
+	if a.Slot != "" {
+		e.Metadata["slot"] = a.Slot
+	}
+
+	switch a.Kind {
+	case SimulateBuy:
+		e.EventName = "minter:buy"
+		e.Data["value"] = amount.Hex()
+	case SimulateClaimYield:
+		e.EventName = "yielder:claim"
+		e.Data["amount"] = amount.Hex()
+	case SimulateClaimOffset:
+		// offseter claims are in grams, 1tCO2 = 10^6g
+		e.EventName = "offseter:claim"
+		e.Data["amount"] = amount.Hex()
+	case SimulateStake, SimulateUnstake:
+		contract := a.Contract
+		if contract == "" {
+			contract = "yielder"
+		}
+		if contract != "yielder" && contract != "offseter" {
+			return DomainEvent{}, ErrInvalidSimulatedAction
+		}
+		action := "deposit"
+		if a.Kind == SimulateUnstake {
+			action = "withdraw"
+		}
+		e.EventName = contract + ":" + action
+		e.Data["value"] = amount.Hex()
+	default:
+		return DomainEvent{}, ErrInvalidSimulatedAction
+	}
+	return e, nil
+}
+
+func SimulatedEvents(wallet string, actions []SimulatedAction, at time.Time) ([]DomainEvent, error) {
+	if len(actions) == 0 {
+		return nil, ErrInvalidSimulatedAction
+	}
+	if len(actions) > MaxSimulatedActions {
+		return nil, ErrTooManySimulatedAction
+	}
+	var events []DomainEvent
+	for i, a := range actions {
+		e, err := a.Event(wallet, i, at)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i, err)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// Copy of ranking with extra events scored as own events of wallet, history builders accrue until given time
+func (pr *PersonnalRanking) Simulate(events []DomainEvent, until time.Time) *PersonnalRanking {
+	own := append(slices.Clone(pr.Events), events...)
+	history := append(slices.Clone(pr.historyEvents()), events...)
+	return NewPersonnalRanking(pr.CustomerWallet, own).WithHistory(history, pr.From, until).WithLinkedWallets(pr.LinkedWallets...)
+}
+
+// Rank line with given score would get, ties are resolved in favor of wallets already ranked
+func ProjectedRank(db *gorm.DB, wallet string, score int64) (int, error) {
+	var above int64
+	err := db.Model(&LeaderboardLine{}).
+		Where(RankedLineCondition).
+		Where("wallet_address <> ? AND score >= ?", wallet, score).
+		Count(&above).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(above) + 1, nil
+}
+
+// Score wallet identity all-time with its real events and the actions it could do now.
+// Horizon lets holding and staking history of wallet accrue after actions are done, current score is computed now
+func (a *PgLeaderboardAggregator) SimulateWallet(rules *ScoringRules, wallet string, actions []SimulatedAction, horizon time.Duration) (*Simulation, error) {
+	scm, err := rules.Manager(FullRules)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	current, err := a.identityRanking(wallet, now)
+	if err != nil {
+		return nil, err
+	}
+	events, err := SimulatedEvents(current.CustomerWallet, actions, now)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Simulation{
+		Simulated:                 current.Simulate(events, now.Add(horizon)).ComputeScore(scm),
+		Current:                   current.ComputeScore(scm),
+		ProjectedRankIsLowerBound: horizon > 0,
+	}
+	var excluded int64
+	if err := a.db.Model(&WalletExclusion{}).Where("wallet_address = ?", current.CustomerWallet).Count(&excluded).Error; err != nil {
+		return nil, err
+	}
+	if excluded > 0 {
+		return res, nil
+	}
+	var line LeaderboardLine
+	err = a.db.Where("wallet_address = ?", current.CustomerWallet).First(&line).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	res.CurrentRank = line.Rank
+	res.ProjectedRank, err = ProjectedRank(a.db, current.CustomerWallet, res.Simulated.Score)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Score simulation", func() {
+	now := time.Unix(t0, 0)
+
+	It("should parse amounts into 10^6 units", func() {
+		v, err := leaderboard.ParseAmount("100.07")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Hex()).To(Equal("0x5f6f270"))
+		v, err = leaderboard.ParseAmount("12")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(v.Uint64()).To(Equal(uint64(12000000)))
+
+		for _, invalid := range []string{"", "0", "-1", "1.0000001", "abc"} {
+			_, err = leaderboard.ParseAmount(invalid)
+			Expect(err).To(MatchError(leaderboard.ErrInvalidSimulatedAction))
+		}
+	})
+
+	It("should build events the way indexer stores them", func() {
+		events, err := leaderboard.SimulatedEvents(holder, []leaderboard.SimulatedAction{
+			{Kind: leaderboard.SimulateBuy, Project: "Karathuru", Slot: "0x1", Amount: "100"},
+			{Kind: leaderboard.SimulateClaimOffset, Project: "Karathuru", Amount: "2"},
+			{Kind: leaderboard.SimulateStake, Project: "Karathuru", Contract: "offseter", Amount: "50"},
+		}, now)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events[0].EventName).To(Equal("minter:buy"))
+		Expect(events[0].Data["value"]).To(Equal("0x5f5e100"))
+		Expect(events[0].Metadata["slot"]).To(Equal("0x1"))
+		Expect(events[1].EventName).To(Equal("offseter:claim"))
+		Expect(events[1].Data["amount"]).To(Equal("0x1e8480"))
+		Expect(events[2].EventName).To(Equal("offseter:deposit"))
+		Expect(events[0].EventId).NotTo(Equal(events[1].EventId))
+
+		_, err = leaderboard.SimulatedEvents(holder, []leaderboard.SimulatedAction{{Kind: "mint", Project: "Karathuru", Amount: "1"}}, now)
+		Expect(err).To(MatchError(leaderboard.ErrInvalidSimulatedAction))
+		_, err = leaderboard.SimulatedEvents(holder, []leaderboard.SimulatedAction{{Kind: leaderboard.SimulateStake, Project: "Karathuru", Contract: "minter", Amount: "1"}}, now)
+		Expect(err).To(MatchError(leaderboard.ErrInvalidSimulatedAction))
+	})
+
+	It("should score simulated actions along with real events", func() {
+		scm := leaderboard.FullScoreCalculatorManager(newGivenValueMinterValueAggregator(50000))
+		real := []leaderboard.DomainEvent{buyProjectEvt("Karathuru", 100*1000000)}
+		events, err := leaderboard.SimulatedEvents(holder, []leaderboard.SimulatedAction{
+			{Kind: leaderboard.SimulateClaimOffset, Project: "Karathuru", Amount: "2"},
+		}, now)
+		Expect(err).NotTo(HaveOccurred())
+
+		pr := leaderboard.NewPersonnalRanking(holder, real)
+		simulated := pr.Simulate(events, pr.Until).ComputeScore(scm)
+		current := pr.ComputeScore(scm)
+
+		Expect(pr.Events).To(HaveLen(1))
+		Expect(simulated.Points).To(HaveLen(len(current.Points) + 1))
+		Expect(simulated.Score - current.Score).To(Equal(int64(200)))
+		Expect(simulated.Categories.Fund).To(Equal(current.Categories.Fund))
+	})
+
+	It("should only accrue history of simulated ranking over horizon", func() {
+		scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithHistoryBuilders(&leaderboard.StakingScoreCalculator{Rates: map[string]uint64{
+			leaderboard.YielderContractType: 1,
+		}}))
+		real := []leaderboard.DomainEvent{stakingEvt("yielder:deposit", "Banegas Farm", "0x3b9aca00", t0)}
+		pr := leaderboard.NewPersonnalRanking(holder, real).WithHistory(real, time.Time{}, time.Unix(t0+10*day, 0))
+		events, err := leaderboard.SimulatedEvents(holder, []leaderboard.SimulatedAction{
+			{Kind: leaderboard.SimulateStake, Project: "Banegas Farm", Amount: "1000"},
+		}, time.Unix(t0+10*day, 0))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pr.ComputeScore(scm).TotalScore).To(Equal("10"))
+		// 1000$ for 20 days and 1000$ for 10 days
+		Expect(pr.Simulate(events, time.Unix(t0+20*day, 0)).ComputeScore(scm).TotalScore).To(Equal("30"))
+	})
+
+	It("should tell rank change", func() {
+		Expect((&leaderboard.Simulation{CurrentRank: 10, ProjectedRank: 4}).RankChange()).To(Equal(6))
+		Expect((&leaderboard.Simulation{CurrentRank: 0, ProjectedRank: 4}).RankChange()).To(Equal(0))
+	})
+})
@@ -0,0 +1,108 @@
+package leaderboard
+
+import (
+	"fmt"
+	"strings"
+
+	u256 "github.com/holiman/uint256"
+)
+
+// How boosts matching the same score are combined
+type StackingPolicy string
+
+const (
+	// Each boost multiplies the previously boosted points
+	MultiplicativeStacking StackingPolicy = "multiplicative"
+	// Boost bonuses are summed up, x2 and x1.5 give x2.5
+	AdditiveStacking StackingPolicy = "additive"
+	// Only the highest boost is applied
+	MaxStacking StackingPolicy = "max"
+)
+
+func StackingPolicyFromString(s string) (StackingPolicy, error) {
+	switch StackingPolicy(s) {
+	case "":
+		return MultiplicativeStacking, nil
+	case MultiplicativeStacking, AdditiveStacking, MaxStacking:
+		return StackingPolicy(s), nil
+	}
+	return "", fmt.Errorf("unknown stacking policy %q", s)
+}
+
+// Stack boosts on base points. capCoef is the maximum overall coef in percent, 0 means no cap.
+// Returns final points and whether cap has been reached
+func (p StackingPolicy) Stack(base *u256.Int, boosts []Boost, capCoef uint64) (*u256.Int, bool) {
+	if len(boosts) == 0 {
+		return base, false
+	}
+
+	var points *u256.Int
+	switch p {
+	case AdditiveStacking:
+		coef := int64(100)
+		for _, b := range boosts {
+			coef += int64(b.Value) - 100
+		}
+		if coef < 0 {
+			coef = 0
+		}
+		points = mulCoeficient(base, u256.NewInt(uint64(coef)))
+	case MaxStacking:
+		coef := boosts[0].Value
+		for _, b := range boosts[1:] {
+			coef = max(coef, b.Value)
+		}
+		points = mulCoeficient(base, u256.NewInt(uint64(coef)))
+	default:
+		// NOTE: apply sequentially to keep rounding of historical computation
+		points = base
+		for _, b := range boosts {
+			points = mulCoeficient(points, u256.NewInt(uint64(b.Value)))
+		}
+	}
+
+	if capCoef == 0 {
+		return points, false
+	}
+	capped := mulCoeficient(base, u256.NewInt(capCoef))
+	if points.Cmp(capped) > 0 {
+		return capped, true
+	}
+	return points, false
+}
+
+// Detail of how point value has been computed
+type PointBreakdown struct {
+	Stacking StackingPolicy `json:"stacking"`
+	Boosts   []Boost        `json:"boosts"`
+	Base     int            `json:"base"`
+	Final    int            `json:"final"`
+	Capped   bool           `json:"capped"`
+}
+
+func PointBreakdownFromScore(s *Score) *PointBreakdown {
+	base := s.Base
+	if base == nil {
+		base = s.Points
+	}
+	boosts := s.Boosts
+	if boosts == nil {
+		boosts = []Boost{}
+	}
+	return &PointBreakdown{
+		Stacking: s.Stacking,
+		Boosts:   boosts,
+		Base:     signedPoints(base, s.Negative),
+		Final:    signedPoints(s.Points, s.Negative),
+		Capped:   s.Capped,
+	}
+}
+
+// Display boosts the way UI used to receive them. e.g. "x2.0 - Funding Karathuru // x3.0 - Funding Value"
+func BoostsDisplay(boosts []Boost) string {
+	var res []string
+	for _, b := range boosts {
+		res = append(res, fmt.Sprintf("x%.1f - %s", float32(b.Value)/100, b.DisplayName))
+	}
+	return strings.Join(res, " // ")
+}
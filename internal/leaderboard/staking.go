@@ -0,0 +1,129 @@
+package leaderboard
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	u256 "github.com/holiman/uint256"
+)
+
+const (
+	YielderContractType  string = "yielder"
+	OffseterContractType string = "offseter"
+)
+
+var defaultStakingRates = map[string]uint64{
+	YielderContractType:  2,
+	OffseterContractType: 2,
+}
+
+// Staking - Calculate points based on project value deposited in yielders and offseters over time
+type StakingScoreCalculator struct {
+	// Points per 1000$ staked for a day indexed by contract type, defaults to defaultStakingRates
+	Rates map[string]uint64
+}
+
+type stakedPosition struct {
+	value *u256.Int
+	// sum of value * seconds staked
+	valueSeconds *u256.Int
+	contractType string
+	project      string
+	slot         string
+}
+
+func (sc *StakingScoreCalculator) rate(contractType string) uint64 {
+	rates := sc.Rates
+	if rates == nil {
+		rates = defaultStakingRates
+	}
+	return rates[contractType]
+}
+
+// Deposits and withdrawals are aggregated per contract type and project.
+// Withdrawals reduce the staked value, several deposits add up.
+func (sc *StakingScoreCalculator) ComputeHistory(wallet string, events []DomainEvent, from time.Time, until time.Time) []Score {
+	positions := make(map[string]*stakedPosition)
+	last := from
+
+	accrue := func(t time.Time) {
+		if t.After(until) {
+			t = until
+		}
+		if !t.After(last) {
+			return
+		}
+		seconds := u256.NewInt(uint64(t.Sub(last).Seconds()))
+		for _, p := range positions {
+			var vs u256.Int
+			vs.Mul(p.value, seconds)
+			p.valueSeconds.Add(p.valueSeconds, &vs)
+		}
+		last = t
+	}
+
+	for _, e := range events {
+		if e.RecordedAt.After(until) {
+			break
+		}
+		contractType, action, found := strings.Cut(e.EventName, ":")
+		if !found || (action != "deposit" && action != "withdraw") || !starknet.SameAddress(e.WalletAddress, wallet) {
+			continue
+		}
+		if sc.rate(contractType) == 0 {
+			continue
+		}
+		value, err := u256.FromHex(e.Data["value"])
+		if err != nil {
+			continue
+		}
+
+		accrue(e.RecordedAt)
+
+		key := contractType + "#" + e.Metadata["project_name"]
+		p, exists := positions[key]
+		if !exists {
+			p = &stakedPosition{value: u256.NewInt(0), valueSeconds: u256.NewInt(0), contractType: contractType, project: e.Metadata["project_name"], slot: e.Metadata["slot"]}
+			positions[key] = p
+		}
+		if action == "deposit" {
+			p.value.Add(p.value, value)
+		} else if p.value.Cmp(value) <= 0 {
+			p.value.Clear()
+		} else {
+			p.value.Sub(p.value, value)
+		}
+	}
+	accrue(until)
+
+	var keys []string
+	for k := range positions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var scores []Score
+	for _, k := range keys {
+		p := positions[k]
+		// NOTE: value is already multiplied by 10^6
+		var points u256.Int
+		points.Mul(p.valueSeconds, u256.NewInt(sc.rate(p.contractType)))
+		points.Div(&points, u256.NewInt(secondsPerDay*1000))
+		if points.IsZero() {
+			continue
+		}
+		scores = append(scores, Score{
+			Points: &points,
+			Rule:   StakingRuleName,
+			Event: DomainEvent{
+				RecordedAt:    until,
+				EventName:     p.contractType + ":staking",
+				WalletAddress: wallet,
+				Metadata:      EventMetadata{"project_name": p.project, "slot": p.slot},
+			},
+		})
+	}
+	return scores
+}
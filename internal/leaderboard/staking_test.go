@@ -0,0 +1,67 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func stakingEvt(name string, project string, value string, ts int64) leaderboard.DomainEvent {
+	return newEvent(name+value, name, map[string]string{"address": holder, "value": value}, map[string]string{"slot": "0x1", "project_name": project}, []string{}, ts)
+}
+
+func stakingLine(events []leaderboard.DomainEvent, until int64) *leaderboard.LeaderboardLine {
+	scm := leaderboard.NewScoreCalculatorManager(leaderboard.WithHistoryBuilders(&leaderboard.StakingScoreCalculator{Rates: map[string]uint64{
+		leaderboard.YielderContractType:  1,
+		leaderboard.OffseterContractType: 2,
+	}}))
+	return leaderboard.NewPersonnalRanking(holder, events).WithHistory(events, time.Time{}, time.Unix(until, 0)).ComputeScore(scm)
+}
+
+var _ = Describe("StakingScoreCalculator", func() {
+	// 1000$ and 500$
+	thousand, fiveHundred := "0x3b9aca00", "0x1dcd6500"
+
+	It("should award points per dollar-day staked", func() {
+		line := stakingLine([]leaderboard.DomainEvent{stakingEvt("yielder:deposit", "Banegas Farm", thousand, t0)}, t0+10*day)
+		Expect(line.TotalScore).To(Equal("10"))
+		Expect(line.Categories.Farming).To(Equal("10"))
+	})
+
+	It("should apply rate of contract type", func() {
+		line := stakingLine([]leaderboard.DomainEvent{stakingEvt("offseter:deposit", "Banegas Farm", thousand, t0)}, t0+10*day)
+		Expect(line.TotalScore).To(Equal("20"))
+	})
+
+	It("should handle partial withdrawals and multiple deposits", func() {
+		events := []leaderboard.DomainEvent{
+			stakingEvt("yielder:deposit", "Banegas Farm", thousand, t0),
+			// 1000$ for 4 days then 500$ for 2 days
+			stakingEvt("yielder:withdraw", "Banegas Farm", fiveHundred, t0+4*day),
+			// 1500$ for 4 days
+			stakingEvt("yielder:deposit", "Banegas Farm", thousand, t0+6*day),
+		}
+		Expect(stakingLine(events, t0+10*day).TotalScore).To(Equal("11"))
+	})
+
+	It("should stop accruing after full withdrawal", func() {
+		events := []leaderboard.DomainEvent{
+			stakingEvt("yielder:deposit", "Banegas Farm", thousand, t0),
+			stakingEvt("yielder:withdraw", "Banegas Farm", thousand, t0+3*day),
+		}
+		Expect(stakingLine(events, t0+10*day).TotalScore).To(Equal("3"))
+	})
+
+	It("should compute each project separately", func() {
+		events := []leaderboard.DomainEvent{
+			stakingEvt("yielder:deposit", "Banegas Farm", thousand, t0),
+			stakingEvt("yielder:withdraw", "Las Delicias", thousand, t0+day),
+		}
+		line := stakingLine(events, t0+10*day)
+		Expect(line.TotalScore).To(Equal("10"))
+		Expect(len(line.Points)).To(Equal(1))
+		Expect(line.Points[0].Metadata["project_name"]).To(Equal("Banegas Farm"))
+	})
+})
@@ -0,0 +1,275 @@
+package leaderboard
+
+import (
+	"database/sql/driver"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// Value bought on minters in $, includes airdrops as minter value does
+	FundedStat string = "funded"
+	// Carbon offset by claims on offseters in tCO2
+	OffsetStat string = "offset"
+	// Yield claimed on yielders in $
+	YieldStat string = "yield"
+	// Unique participants since launch at end of bucket
+	ParticipantsStat string = "participants"
+	// Participants whose first event is in bucket
+	NewParticipantsStat string = "new_participants"
+
+	DailyStats  string = "day"
+	WeeklyStats string = "week"
+)
+
+var ErrInvalidStat = errors.New("unknown stat metric or interval")
+
+// One bucket of a protocol time series, replaced by each aggregator run.
+// Amounts are stored in 10^6 units, participants as plain counts
+type ProtocolStat struct {
+	ComputedAt time.Time
+	Metric     string `gorm:"index:idx_protocol_stats_lookup,priority:1"`
+	Period     string `gorm:"index:idx_protocol_stats_lookup,priority:2"`
+	// Empty for protocol wide series
+	Project string `gorm:"index:idx_protocol_stats_lookup,priority:3"`
+	Slot    string `gorm:"index:idx_protocol_stats_lookup,priority:4"`
+	Bucket  time.Time
+	Value   Numeric   `gorm:"type:numeric"`
+	ID      ulid.ULID `gorm:"primaryKey"`
+}
+
+// u256 stored in a numeric column as a decimal string.
+// NOTE: u256.Int only implements driver.Valuer on its pointer, gorm would expand its underlying array
+type Numeric struct {
+	u256.Int
+}
+
+func (n Numeric) Value() (driver.Value, error) {
+	return n.Int.Dec(), nil
+}
+
+func (n *Numeric) Scan(value interface{}) error {
+	return n.Int.Scan(value)
+}
+
+// Columns of domain events stats are computed from
+type StatEvent struct {
+	RecordedAt    time.Time
+	EventName     string
+	WalletAddress string
+	Value         string
+	Amount        string
+	Project       string
+	Slot          string
+}
+
+const statEventsQuery = `SELECT recorded_at, event_name, wallet_address, data->>'value' AS value, data->>'amount' AS amount,
+	metadata->>'project_name' AS project, metadata->>'slot' AS slot
+	FROM domain_events
+	WHERE source = 'chain' AND wallet_address NOT IN (SELECT wallet_address FROM wallet_exclusions WHERE source = ?)
+	ORDER BY recorded_at ASC`
+
+func IsCumulativeStat(metric string) bool {
+	return metric == ParticipantsStat
+}
+
+func ValidateStat(metric string, interval string) error {
+	switch metric {
+	case FundedStat, OffsetStat, YieldStat, ParticipantsStat, NewParticipantsStat:
+	default:
+		return ErrInvalidStat
+	}
+	if interval != DailyStats && interval != WeeklyStats {
+		return ErrInvalidStat
+	}
+	return nil
+}
+
+// Start of bucket in UTC, weeks start on monday
+func StatBucket(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if interval == WeeklyStats {
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+func statAmount(e StatEvent) (string, string) {
+	switch e.EventName {
+	case "minter:buy", "minter:airdrop":
+		return FundedStat, e.Value
+	case "offseter:claim":
+		// claimed grams are tCO2 in 10^6 units
+		return OffsetStat, e.Amount
+	case "yielder:claim":
+		return YieldStat, e.Amount
+	}
+	return "", ""
+}
+
+type statKey struct {
+	metric  string
+	project string
+	slot    string
+	bucket  time.Time
+}
+
+// Daily and weekly series, amounts are split by project and slot along with protocol wide totals.
+// Events must be sorted by date
+func ComputeProtocolStats(events []StatEvent, now time.Time) []ProtocolStat {
+	var stats []ProtocolStat
+	for _, interval := range []string{DailyStats, WeeklyStats} {
+		values := make(map[statKey]*u256.Int)
+		add := func(k statKey, v *u256.Int) {
+			if _, exists := values[k]; !exists {
+				values[k] = u256.NewInt(0)
+			}
+			values[k].Add(values[k], v)
+		}
+
+		seen := make(map[string]bool)
+		var buckets []time.Time
+		for _, e := range events {
+			bucket := StatBucket(e.RecordedAt, interval)
+			if len(buckets) == 0 || !buckets[len(buckets)-1].Equal(bucket) {
+				buckets = append(buckets, bucket)
+			}
+			if !seen[e.WalletAddress] {
+				seen[e.WalletAddress] = true
+				add(statKey{metric: NewParticipantsStat, bucket: bucket}, u256.NewInt(1))
+			}
+
+			metric, amount := statAmount(e)
+			if metric == "" {
+				continue
+			}
+			v, err := u256.FromHex(amount)
+			if err != nil {
+				log.Error("failed to parse stat amount", "event", e.EventName, "amount", amount, "error", err)
+				continue
+			}
+			add(statKey{metric: metric, bucket: bucket}, v)
+			if e.Project != "" {
+				add(statKey{metric: metric, project: e.Project, bucket: bucket}, v)
+				if e.Slot != "" {
+					add(statKey{metric: metric, project: e.Project, slot: e.Slot, bucket: bucket}, v)
+				}
+			}
+		}
+
+		// running total of participants, buckets without new participant are kept
+		total := u256.NewInt(0)
+		for _, b := range buckets {
+			if n, exists := values[statKey{metric: NewParticipantsStat, bucket: b}]; exists {
+				total.Add(total, n)
+			}
+			values[statKey{metric: ParticipantsStat, bucket: b}] = new(u256.Int).Set(total)
+		}
+
+		for k, v := range values {
+			stats = append(stats, ProtocolStat{ComputedAt: now, Metric: k.metric, Period: interval, Project: k.project, Slot: k.slot, Bucket: k.bucket, Value: Numeric{*v}, ID: ulid.Make()})
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		if a.Project != b.Project {
+			return a.Project < b.Project
+		}
+		if a.Slot != b.Slot {
+			return a.Slot < b.Slot
+		}
+		return a.Bucket.Before(b.Bucket)
+	})
+	return stats
+}
+
+// State of chain events stats are computed from, series only change when it does
+type StatsWatermark struct {
+	Events   int64
+	Wallets  int64
+	LatestAt time.Time
+}
+
+const statsWatermarkQuery = `SELECT COUNT(*) AS events, COUNT(DISTINCT wallet_address) AS wallets, COALESCE(MAX(recorded_at), to_timestamp(0)) AS latest_at
+	FROM domain_events WHERE source = 'chain'`
+
+func (w StatsWatermark) Equal(o StatsWatermark) bool {
+	return w.Events == o.Events && w.Wallets == o.Wallets && w.LatestAt.Equal(o.LatestAt)
+}
+
+func GetStatsWatermark(db *gorm.DB) (StatsWatermark, error) {
+	var w StatsWatermark
+	err := db.Raw(statsWatermarkQuery).Scan(&w).Error
+	return w, err
+}
+
+// Recompute every series from domain events, readers keep previous series until transaction commits
+func SaveProtocolStats(db *gorm.DB, now time.Time) error {
+	var events []StatEvent
+	if err := db.Raw(statEventsQuery, ExclusionSourceContract).Scan(&events).Error; err != nil {
+		return err
+	}
+	stats := ComputeProtocolStats(events, now)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&ProtocolStat{}).Error; err != nil {
+			return err
+		}
+		if len(stats) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(stats, 500).Error
+	})
+}
+
+type StatSeries struct {
+	Metric   string
+	Interval string
+	Project  string
+	Slot     string
+	Points   []ProtocolStat
+	// Last point of cumulative series, sum of points otherwise
+	Total u256.Int
+	// Zero when series was never computed
+	ComputedAt time.Time
+}
+
+// Buckets of one series starting in [from, until), bounds are optional
+func GetStatSeries(db *gorm.DB, metric string, interval string, project string, slot string, from *time.Time, until *time.Time) (*StatSeries, error) {
+	if err := ValidateStat(metric, interval); err != nil {
+		return nil, err
+	}
+	q := db.Where("metric = ? AND period = ? AND project = ? AND slot = ?", metric, interval, project, slot)
+	if from != nil {
+		q = q.Where("bucket >= ?", StatBucket(*from, interval))
+	}
+	if until != nil {
+		q = q.Where("bucket < ?", *until)
+	}
+	series := &StatSeries{Metric: metric, Interval: interval, Project: project, Slot: slot, Points: []ProtocolStat{}}
+	if err := q.Order("bucket ASC").Find(&series.Points).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range series.Points {
+		if IsCumulativeStat(metric) {
+			series.Total.Set(&p.Value.Int)
+		} else {
+			series.Total.Add(&series.Total, &p.Value.Int)
+		}
+		series.ComputedAt = p.ComputedAt
+	}
+	return series, nil
+}
@@ -0,0 +1,104 @@
+package leaderboard_test
+
+import (
+	"time"
+
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	u256 "github.com/holiman/uint256"
+	"github.com/oklog/ulid/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func findStat(stats []leaderboard.ProtocolStat, metric string, period string, project string, slot string, bucket time.Time) *leaderboard.ProtocolStat {
+	for i := range stats {
+		s := stats[i]
+		if s.Metric == metric && s.Period == period && s.Project == project && s.Slot == slot && s.Bucket.Equal(bucket) {
+			return &stats[i]
+		}
+	}
+	return nil
+}
+
+var _ = Describe("Protocol stats", func() {
+	// wednesday 2024-01-03, thursday and next monday
+	wednesday := time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)
+	thursday := wednesday.Add(24 * time.Hour)
+	monday := time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC)
+	weekStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []leaderboard.StatEvent{
+		{RecordedAt: wednesday, EventName: "minter:buy", WalletAddress: "0x1", Value: "0x5f5e100", Project: "Karathuru", Slot: "0x1"},
+		{RecordedAt: wednesday, EventName: "minter:buy", WalletAddress: "0x2", Value: "0x2faf080", Project: "Banegas Farm", Slot: "0x2"},
+		{RecordedAt: thursday, EventName: "offseter:claim", WalletAddress: "0x1", Amount: "0x1e8480", Project: "Karathuru", Slot: "0x1"},
+		{RecordedAt: monday, EventName: "yielder:claim", WalletAddress: "0x3", Amount: "0xf4240", Project: "Karathuru", Slot: "0x1"},
+		{RecordedAt: monday, EventName: "project:transfer", WalletAddress: "0x1"},
+	}
+
+	It("should bucket dates by day and by week starting on monday", func() {
+		Expect(leaderboard.StatBucket(wednesday, leaderboard.DailyStats)).To(Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)))
+		Expect(leaderboard.StatBucket(wednesday, leaderboard.WeeklyStats)).To(Equal(weekStart))
+		Expect(leaderboard.StatBucket(weekStart.Add(-time.Second), leaderboard.WeeklyStats)).To(Equal(time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should sum amounts by project and slot along with protocol total", func() {
+		stats := leaderboard.ComputeProtocolStats(events, monday)
+		day := leaderboard.StatBucket(wednesday, leaderboard.DailyStats)
+
+		total := findStat(stats, leaderboard.FundedStat, leaderboard.DailyStats, "", "", day)
+		Expect(total.Value.Uint64()).To(Equal(uint64(150000000)))
+		karathuru := findStat(stats, leaderboard.FundedStat, leaderboard.DailyStats, "Karathuru", "0x1", day)
+		Expect(karathuru.Value.Uint64()).To(Equal(uint64(100000000)))
+
+		week := findStat(stats, leaderboard.OffsetStat, leaderboard.WeeklyStats, "Karathuru", "", weekStart)
+		Expect(week.Value.Uint64()).To(Equal(uint64(2000000)))
+		Expect(findStat(stats, leaderboard.YieldStat, leaderboard.WeeklyStats, "", "", weekStart)).To(BeNil())
+	})
+
+	It("should count new and unique participants", func() {
+		stats := leaderboard.ComputeProtocolStats(events, monday)
+		nextWeek := leaderboard.StatBucket(monday, leaderboard.WeeklyStats)
+
+		Expect(findStat(stats, leaderboard.NewParticipantsStat, leaderboard.WeeklyStats, "", "", weekStart).Value.Uint64()).To(Equal(uint64(2)))
+		Expect(findStat(stats, leaderboard.NewParticipantsStat, leaderboard.WeeklyStats, "", "", nextWeek).Value.Uint64()).To(Equal(uint64(1)))
+		Expect(findStat(stats, leaderboard.ParticipantsStat, leaderboard.WeeklyStats, "", "", nextWeek).Value.Uint64()).To(Equal(uint64(3)))
+
+		// no new participant on thursday, running total is kept
+		thursdayBucket := leaderboard.StatBucket(thursday, leaderboard.DailyStats)
+		Expect(findStat(stats, leaderboard.NewParticipantsStat, leaderboard.DailyStats, "", "", thursdayBucket)).To(BeNil())
+		Expect(findStat(stats, leaderboard.ParticipantsStat, leaderboard.DailyStats, "", "", thursdayBucket).Value.Uint64()).To(Equal(uint64(2)))
+	})
+
+	It("should validate metric and interval", func() {
+		Expect(leaderboard.ValidateStat(leaderboard.FundedStat, leaderboard.WeeklyStats)).To(Succeed())
+		Expect(leaderboard.ValidateStat("volume", leaderboard.DailyStats)).To(MatchError(leaderboard.ErrInvalidStat))
+		Expect(leaderboard.ValidateStat(leaderboard.FundedStat, "month")).To(MatchError(leaderboard.ErrInvalidStat))
+	})
+
+	It("should only see new stats input when chain events changed", func() {
+		w := leaderboard.StatsWatermark{Events: 5, Wallets: 3, LatestAt: monday}
+		Expect(w.Equal(leaderboard.StatsWatermark{Events: 5, Wallets: 3, LatestAt: monday.In(time.FixedZone("CET", 3600))})).To(BeTrue())
+		Expect(w.Equal(leaderboard.StatsWatermark{Events: 6, Wallets: 3, LatestAt: monday})).To(BeFalse())
+		// address migration changes wallets without new event
+		Expect(w.Equal(leaderboard.StatsWatermark{Events: 5, Wallets: 2, LatestAt: monday})).To(BeFalse())
+		Expect(w.Equal(leaderboard.StatsWatermark{})).To(BeFalse())
+	})
+
+	It("should persist and read value as a decimal numeric", func() {
+		db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		value := u256.MustFromDecimal("150000000000000000000000")
+		stat := leaderboard.ProtocolStat{Metric: leaderboard.FundedStat, Period: leaderboard.DailyStats, Bucket: weekStart, Value: leaderboard.Numeric{Int: *value}, ID: ulid.Make()}
+		stmt := db.Create(&stat).Statement
+		Expect(stmt.Error).NotTo(HaveOccurred())
+		Expect(stmt.Vars).To(HaveLen(8))
+		Expect(db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...)).To(ContainSubstring("'150000000000000000000000'"))
+
+		var read leaderboard.ProtocolStat
+		Expect(read.Value.Scan([]byte("150000000000000000000000"))).To(Succeed())
+		Expect(read.Value.Int).To(Equal(*value))
+	})
+})
@@ -0,0 +1,172 @@
+package leaderboard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/jackc/pgx/v5"
+	"github.com/oklog/ulid/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// Postgres channel aggregator notifies once lines are swapped, payload is the run id
+	UpdatesChannel string = "leaderboard_updated"
+	// Score changes are only kept for listeners that lag behind
+	scoreChangesRetention = 1 * time.Hour
+	// Updates are dropped for subscribers that do not read them
+	subscriberBuffer = 8
+)
+
+// Line of all-time leaderboard that moved during one aggregator run.
+// Previous values are nil for wallets ranked for the first time
+type ScoreChange struct {
+	CreatedAt          time.Time `gorm:"index"`
+	RunID              ulid.ULID `gorm:"primaryKey"`
+	WalletAddress      string    `gorm:"primaryKey"`
+	TotalScore         string
+	PreviousTotalScore *string
+	Rank               int
+	PreviousRank       *int
+}
+
+type LeaderboardUpdate struct {
+	RunID     ulid.ULID
+	UpdatedAt time.Time
+	Changes   []ScoreChange
+}
+
+// Whether one of first top lines changed, before or after run
+func (u LeaderboardUpdate) TopChanged(top int) bool {
+	for _, c := range u.Changes {
+		if (c.Rank > 0 && c.Rank <= top) || (c.PreviousRank != nil && *c.PreviousRank > 0 && *c.PreviousRank <= top) {
+			return true
+		}
+	}
+	return false
+}
+
+func (u LeaderboardUpdate) WalletChange(wallet string) (*ScoreChange, bool) {
+	for i := range u.Changes {
+		if u.Changes[i].WalletAddress == wallet {
+			return &u.Changes[i], true
+		}
+	}
+	return nil, false
+}
+
+// Diff of swapped tables, must run before old lines are dropped
+func recordScoreChanges(db *gorm.DB, runID ulid.ULID, now time.Time) error {
+	err := db.Exec(`INSERT INTO score_changes (created_at, run_id, wallet_address, total_score, previous_total_score, rank, previous_rank)
+		SELECT ?, ?, l.wallet_address, l.total_score, o.total_score, l.rank, o.rank
+		FROM leaderboard_lines l LEFT JOIN leaderboard_lines_old o ON o.wallet_address = l.wallet_address
+		WHERE o.id IS NULL OR o.total_score <> l.total_score OR o.rank IS DISTINCT FROM l.rank`, now, runID).Error
+	if err != nil {
+		return err
+	}
+	return db.Where("created_at < ?", now.Add(-scoreChangesRetention)).Delete(&ScoreChange{}).Error
+}
+
+func notifyUpdate(db *gorm.DB, runID ulid.ULID) error {
+	return db.Exec("SELECT pg_notify(?, ?)", UpdatesChannel, runID.String()).Error
+}
+
+func GetLeaderboardUpdate(db *gorm.DB, runID ulid.ULID) (*LeaderboardUpdate, error) {
+	var changes []ScoreChange
+	if err := db.Where("run_id = ?", runID).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	u := &LeaderboardUpdate{RunID: runID, UpdatedAt: ulid.Time(runID.Time()), Changes: changes}
+	return u, nil
+}
+
+// Fans leaderboard updates out to in-process subscribers
+type UpdateBroker struct {
+	mu   sync.Mutex
+	subs map[int]chan LeaderboardUpdate
+	next int
+}
+
+func NewUpdateBroker() *UpdateBroker {
+	return &UpdateBroker{subs: make(map[int]chan LeaderboardUpdate)}
+}
+
+// Channel is closed once subscription is cancelled
+func (b *UpdateBroker) Subscribe() (<-chan LeaderboardUpdate, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	ch := make(chan LeaderboardUpdate, subscriberBuffer)
+	b.subs[id] = ch
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, exists := b.subs[id]; exists {
+			delete(b.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (b *UpdateBroker) Publish(u LeaderboardUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, ch := range b.subs {
+		select {
+		case ch <- u:
+		default:
+			log.Warn("dropping leaderboard update for slow subscriber", "subscriber", id, "run", u.RunID)
+		}
+	}
+}
+
+// Listen to aggregator notifications and publish updates to broker until context is done.
+// Uses its own connection as LISTEN is bound to one session
+func ListenUpdates(ctx context.Context, dsn string, db *gorm.DB, broker *UpdateBroker) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+UpdatesChannel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		runID, err := ulid.Parse(n.Payload)
+		if err != nil {
+			log.Error("invalid leaderboard update notification", "payload", n.Payload, "error", err)
+			continue
+		}
+		u, err := GetLeaderboardUpdate(db, runID)
+		if err != nil {
+			log.Error("failed to get leaderboard update", "run", runID, "error", err)
+			continue
+		}
+		broker.Publish(*u)
+	}
+}
+
+// Keep listening across connection losses
+func RunUpdatesListener(ctx context.Context, dsn string, db *gorm.DB, broker *UpdateBroker) {
+	for ctx.Err() == nil {
+		if err := ListenUpdates(ctx, dsn, db, broker); err != nil {
+			log.Error("leaderboard updates listener stopped", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package leaderboard_test
+
+import (
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/oklog/ulid/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Leaderboard updates", func() {
+	rank := func(r int) *int { return &r }
+
+	It("should tell whether top lines changed", func() {
+		u := leaderboard.LeaderboardUpdate{Changes: []leaderboard.ScoreChange{{WalletAddress: holder, Rank: 12, PreviousRank: rank(15)}}}
+		Expect(u.TopChanged(10)).To(BeFalse())
+		Expect(u.TopChanged(12)).To(BeTrue())
+
+		// line leaving top
+		u = leaderboard.LeaderboardUpdate{Changes: []leaderboard.ScoreChange{{WalletAddress: holder, Rank: 11, PreviousRank: rank(3)}}}
+		Expect(u.TopChanged(10)).To(BeTrue())
+
+		// excluded lines are not ranked
+		u = leaderboard.LeaderboardUpdate{Changes: []leaderboard.ScoreChange{{WalletAddress: holder, Rank: 0, PreviousRank: rank(0)}}}
+		Expect(u.TopChanged(10)).To(BeFalse())
+	})
+
+	It("should find wallet change", func() {
+		u := leaderboard.LeaderboardUpdate{Changes: []leaderboard.ScoreChange{{WalletAddress: holder, TotalScore: "42", Rank: 1}}}
+		c, changed := u.WalletChange(holder)
+		Expect(changed).To(BeTrue())
+		Expect(c.TotalScore).To(Equal("42"))
+		Expect(c.PreviousRank).To(BeNil())
+		_, changed = u.WalletChange("0x1")
+		Expect(changed).To(BeFalse())
+	})
+
+	It("should fan updates out to subscribers", func() {
+		broker := leaderboard.NewUpdateBroker()
+		first, cancelFirst := broker.Subscribe()
+		second, cancelSecond := broker.Subscribe()
+		defer cancelSecond()
+
+		runID := ulid.Make()
+		broker.Publish(leaderboard.LeaderboardUpdate{RunID: runID})
+		Expect((<-first).RunID).To(Equal(runID))
+		Expect((<-second).RunID).To(Equal(runID))
+
+		cancelFirst()
+		cancelFirst()
+		Eventually(first).Should(BeClosed())
+		broker.Publish(leaderboard.LeaderboardUpdate{RunID: ulid.Make()})
+		Expect(second).To(Receive())
+	})
+
+	It("should not block on slow subscribers", func() {
+		broker := leaderboard.NewUpdateBroker()
+		_, cancel := broker.Subscribe()
+		defer cancel()
+		for i := 0; i < 20; i++ {
+			broker.Publish(leaderboard.LeaderboardUpdate{RunID: ulid.Make()})
+		}
+	})
+})
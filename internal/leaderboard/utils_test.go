@@ -26,6 +26,11 @@ func newEvent(evtId string, evtName string, data map[string]string, metadata map
 	}
 }
 
+// Explicit rule setting as parsed from scoring configuration
+func setting(v uint64) *uint64 {
+	return &v
+}
+
 func newProjectTransferEvt(evtId string, data map[string]string, metadata map[string]string, keys []string, ts int64) leaderboard.DomainEvent {
 	return newEvent(evtId, "project:transfer", data, metadata, keys, ts)
 }
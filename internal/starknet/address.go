@@ -0,0 +1,48 @@
+package starknet
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+var ErrInvalidAddress = errors.New("invalid starknet address")
+
+// Canonical form of a contract or account address: lower case hex without zero padding, as felt.String() prints it.
+// Addresses are stored and queried in this form so that a wallet never shows up twice.
+type Address string
+
+// Parse address from any felt representation (padded or not, upper or lower case)
+func NewAddress(s string) (Address, error) {
+	var f felt.Felt
+	if err := f.UnmarshalJSON([]byte(s)); err != nil {
+		return "", ErrInvalidAddress
+	}
+	return Address(f.String()), nil
+}
+
+// Canonical address, s is returned untouched when it is not a felt
+func NormalizeAddress(s string) Address {
+	a, err := NewAddress(s)
+	if err != nil {
+		return Address(s)
+	}
+	return a
+}
+
+func SameAddress(a string, b string) bool {
+	return NormalizeAddress(a) == NormalizeAddress(b)
+}
+
+func (a Address) String() string {
+	return string(a)
+}
+
+// Address left padded to 64 hex chars, as contracts are written in configuration
+func (a Address) Padded() string {
+	return EnsureStarkFelt(string(a))
+}
+
+func (a Address) Felt() *felt.Felt {
+	return FeltFromString(string(a))
+}
@@ -0,0 +1,34 @@
+package starknet_test
+
+import (
+	"testing"
+
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"gotest.tools/assert"
+)
+
+func TestNewAddress(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected starknet.Address
+	}{
+		{name: "canonical", value: "0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2", expected: "0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2"},
+		{name: "padded", value: "0x01e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2", expected: "0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2"},
+		{name: "upper case", value: "0x01E2F67D8132831F210E19C5EE0197AA134308E16F7F284BBA2C72E28FC464D2", expected: "0x1e2f67d8132831f210e19c5ee0197aa134308e16f7f284bba2c72e28fc464d2"},
+		{name: "zero", value: "0x0000", expected: "0x0"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := starknet.NewAddress(tc.value)
+			assert.NilError(t, err)
+			assert.Equal(t, a, tc.expected)
+		})
+	}
+
+	_, err := starknet.NewAddress("not an address")
+	assert.Equal(t, err, starknet.ErrInvalidAddress)
+	assert.Equal(t, starknet.NormalizeAddress("not an address"), starknet.Address("not an address"))
+	assert.Equal(t, starknet.Address("0x2").Padded(), "0x0000000000000000000000000000000000000000000000000000000000000002")
+}
@@ -32,6 +32,7 @@ type DataAvailability struct {
 type Event struct {
 	RecordedAt  time.Time `json:"recorded_at"`
 	EventId     string    `json:"event_id"`
+	BlockNumber uint64    `json:"block_number"`
 	FromAddress string    `json:"from_address"`
 	Keys        []string  `json:"keys"`
 	Data        []string  `json:"data"`
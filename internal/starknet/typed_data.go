@@ -0,0 +1,89 @@
+package starknet
+
+import (
+	"errors"
+
+	"github.com/NethermindEth/juno/core/crypto"
+	"github.com/NethermindEth/juno/core/felt"
+)
+
+var (
+	ErrShortStringTooLong = errors.New("short string must be at most 31 characters")
+
+	// 'VALID' returned by SRC6 accounts
+	validSignature = ShortStringToFelt("VALID")
+)
+
+const starknetDomainType = "StarkNetDomain(name:felt,version:felt,chainId:felt)"
+
+func ChainIdFromNetwork(network StarknetNetwork) string {
+	switch network {
+	case Mainnet:
+		return "SN_MAIN"
+	case Goerli:
+		return "SN_GOERLI"
+	default:
+		return "SN_SEPOLIA"
+	}
+}
+
+// Encode ascii string to felt, cairo short string style
+func ShortStringToFelt(s string) *felt.Felt {
+	return new(felt.Felt).SetBytes([]byte(s))
+}
+
+func ValidShortString(s string) error {
+	if len(s) > 31 {
+		return ErrShortStringTooLong
+	}
+	return nil
+}
+
+// SNIP-12 (revision 0) domain, messages are only valid for the dapp and chain they were signed for
+type TypedDataDomain struct {
+	Name    string
+	Version string
+	ChainId string
+}
+
+func (d TypedDataDomain) Hash() *felt.Felt {
+	typeHash, _ := StarknetKeccak([]byte(starknetDomainType))
+	return crypto.PedersenArray(typeHash, ShortStringToFelt(d.Name), ShortStringToFelt(d.Version), ShortStringToFelt(d.ChainId))
+}
+
+// Message made of felt fields. Type is the encoded struct type e.g. "ReferralClaim(code:felt)"
+type TypedMessage struct {
+	Type   string
+	Fields []*felt.Felt
+}
+
+func (m TypedMessage) Hash() *felt.Felt {
+	typeHash, _ := StarknetKeccak([]byte(m.Type))
+	return crypto.PedersenArray(append([]*felt.Felt{typeHash}, m.Fields...)...)
+}
+
+// Hash wallets sign with their account
+func TypedDataHash(domain TypedDataDomain, account string, message TypedMessage) (*felt.Felt, error) {
+	address := FeltFromString(account)
+	if address == nil {
+		return nil, errors.New("invalid account address")
+	}
+	return crypto.PedersenArray(ShortStringToFelt("StarkNet Message"), domain.Hash(), address, message.Hash()), nil
+}
+
+// Ask account contract whether signature is valid for hash
+func IsValidSignature(rpc StarknetRpcClient, account string, hash *felt.Felt, signature []felt.Felt) (bool, error) {
+	params := []felt.Felt{*hash, *FeltFromUint64(uint64(len(signature)))}
+	params = append(params, signature...)
+
+	res, err := rpc.Call(account, "is_valid_signature", params)
+	if err != nil {
+		return false, err
+	}
+	if len(res) == 0 {
+		return false, nil
+	}
+
+	// NOTE: legacy accounts return 1, SRC6 accounts return 'VALID'
+	return res[0].Equal(One) || res[0].Equal(validSignature), nil
+}
@@ -0,0 +1,66 @@
+package starknet_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NethermindEth/juno/core/felt"
+	"github.com/carbonable/leaderboard/internal/starknet"
+	"gotest.tools/assert"
+)
+
+type signatureRpc struct {
+	res []felt.Felt
+	err error
+}
+
+func (c signatureRpc) Call(address string, method string, params []felt.Felt) ([]felt.Felt, error) {
+	return c.res, c.err
+}
+
+func TestShortStringToFelt(t *testing.T) {
+	assert.Equal(t, starknet.ShortStringToFelt("SN_MAIN").String(), "0x534e5f4d41494e")
+	assert.Equal(t, starknet.ChainIdFromNetwork(starknet.Mainnet), "SN_MAIN")
+	assert.Assert(t, starknet.ValidShortString("0123456789012345678901234567890123") != nil)
+}
+
+func TestTypedDataHash(t *testing.T) {
+	domain := starknet.TypedDataDomain{Name: "Carbonable Leaderboard", Version: "1", ChainId: "SN_MAIN"}
+	message := starknet.TypedMessage{Type: "ReferralClaim(code:felt)", Fields: []*felt.Felt{starknet.ShortStringToFelt("carbon")}}
+
+	h1, err := starknet.TypedDataHash(domain, "0x1", message)
+	assert.NilError(t, err)
+	h2, _ := starknet.TypedDataHash(domain, "0x2", message)
+	assert.Assert(t, !h1.Equal(h2), "hash must depend on account")
+
+	domain.ChainId = "SN_SEPOLIA"
+	h3, _ := starknet.TypedDataHash(domain, "0x1", message)
+	assert.Assert(t, !h1.Equal(h3), "hash must depend on chain")
+
+	_, err = starknet.TypedDataHash(domain, "not an address", message)
+	assert.Assert(t, err != nil)
+}
+
+func TestIsValidSignature(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rpc      signatureRpc
+		expected bool
+	}{
+		{name: "legacy account", rpc: signatureRpc{res: []felt.Felt{*starknet.One}}, expected: true},
+		{name: "src6 account", rpc: signatureRpc{res: []felt.Felt{*starknet.ShortStringToFelt("VALID")}}, expected: true},
+		{name: "invalid", rpc: signatureRpc{res: []felt.Felt{*starknet.FeltFromUint64(0)}}, expected: false},
+		{name: "empty", rpc: signatureRpc{}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := starknet.IsValidSignature(tc.rpc, "0x1", starknet.One, []felt.Felt{*starknet.One})
+			assert.NilError(t, err)
+			assert.Equal(t, valid, tc.expected)
+		})
+	}
+
+	_, err := starknet.IsValidSignature(signatureRpc{err: errors.New("reverted")}, "0x1", starknet.One, nil)
+	assert.Assert(t, err != nil)
+}
@@ -2,6 +2,7 @@ package subscriber
 
 import (
 	"github.com/NethermindEth/juno/core/felt"
+	"github.com/carbonable/leaderboard/internal/catalog"
 	"github.com/carbonable/leaderboard/internal/indexer"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
@@ -34,7 +35,7 @@ func MigratorMigrationSubscriber(storage indexer.Storage, db *gorm.DB, rpc stark
 			log.Error("failed to unmarshal slot in felt", "error", err)
 		}
 
-		metadata := getMetadataFromMigrator(rpc, event.FromAddress, slot.Uint64())
+		metadata := getMetadataFromMigrator(db, rpc, event.FromAddress, slot.Uint64())
 
 		evt := leaderboard.DomainEventFromStarknetEvent(event, "migrator:migration", event.Data[0], data, metadata)
 		db.Create(&evt)
@@ -52,14 +53,14 @@ func RegisterMigratorSubscribers(args *SubscriberArgs) error {
 	return nil
 }
 
-func getMetadataFromMigrator(rpc starknet.StarknetRpcClient, address string, slot uint64) map[string]string {
+func getMetadataFromMigrator(db *gorm.DB, rpc starknet.StarknetRpcClient, address string, slot uint64) map[string]string {
 	projectAddress, err := starknet.MigratorTargetAddress(rpc, address)
 	if err != nil {
 		log.Error("failed to get project address", "error", err)
 		return map[string]string{}
 	}
 
-	slotUri, err := starknet.GetSlotUri(rpc, projectAddress, slot)
+	slotUri, err := catalog.FetchSlotUri(db, rpc, projectAddress, slot)
 	if err != nil {
 		log.Error("failed to get project slotUri", "error", err)
 		return map[string]string{}
@@ -3,6 +3,7 @@ package subscriber
 import (
 	"errors"
 
+	"github.com/carbonable/leaderboard/internal/catalog"
 	"github.com/carbonable/leaderboard/internal/indexer"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
@@ -29,7 +30,7 @@ func MinterBuySubscriber(storage indexer.Storage, rpc starknet.StarknetRpcClient
 			"time":    event.Data[3],
 		}
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 
 		evt := leaderboard.DomainEventFromStarknetEvent(event, "minter:buy", event.Data[0], data, metadata)
 		db.Create(&evt)
@@ -53,7 +54,7 @@ func MinterAirdropSubscriber(storage indexer.Storage, rpc starknet.StarknetRpcCl
 			"value": event.Data[1],
 			"time":  event.Data[3],
 		}
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 
 		evt := leaderboard.DomainEventFromStarknetEvent(event, "minter:airdrop", event.Data[0], data, metadata)
 		db.Create(&evt)
@@ -74,7 +75,7 @@ func RegisterMinterSubscribers(args *SubscriberArgs) error {
 	return nil
 }
 
-func getMetadataFromEvent(rpc starknet.StarknetRpcClient, address string) map[string]string {
+func getMetadataFromEvent(db *gorm.DB, rpc starknet.StarknetRpcClient, address string) map[string]string {
 	projectAddress, err := starknet.MinterGetProjectAddress(rpc, address)
 	if err != nil {
 		log.Error("failed to get project address", "error", err)
@@ -86,7 +87,7 @@ func getMetadataFromEvent(rpc starknet.StarknetRpcClient, address string) map[st
 		return map[string]string{}
 	}
 
-	slotUri, err := starknet.GetSlotUri(rpc, projectAddress, projectSlot)
+	slotUri, err := catalog.FetchSlotUri(db, rpc, projectAddress, projectSlot)
 	if err != nil {
 		log.Error("failed to get project slotUri", "error", err)
 		return map[string]string{}
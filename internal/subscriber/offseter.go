@@ -19,7 +19,7 @@ func OffseterWithdrawSubscriber(storage indexer.Storage, db *gorm.DB, rpc starkn
 
 		log.Info("offseter:withdraw", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"value":   event.Data[1],
@@ -41,7 +41,7 @@ func OffseterDepositSubscriber(storage indexer.Storage, db *gorm.DB, rpc starkne
 
 		log.Info("offseter:deposit", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"value":   event.Data[1],
@@ -63,7 +63,7 @@ func OffseterClaimSubscriber(storage indexer.Storage, db *gorm.DB, rpc starknet.
 
 		log.Info("offseter:claim", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"amount":  event.Data[1],
@@ -3,6 +3,7 @@ package subscriber
 import (
 	"time"
 
+	"github.com/carbonable/leaderboard/internal/catalog"
 	"github.com/carbonable/leaderboard/internal/indexer"
 	"github.com/carbonable/leaderboard/internal/leaderboard"
 	"github.com/carbonable/leaderboard/internal/starknet"
@@ -29,7 +30,7 @@ func ProjectTransferSubscriber(storage indexer.Storage, db *gorm.DB, rpc starkne
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot of token_id", "error", err)
 		}
-		slotUri, err := starknet.GetSlotUri(rpc, event.FromAddress, slot)
+		slotUri, err := catalog.FetchSlotUri(db, rpc, event.FromAddress, slot)
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot_uri", "error", err)
 		}
@@ -58,7 +59,7 @@ func ProjectTransferValueSubscriber(storage indexer.Storage, db *gorm.DB, rpc st
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot of token_id", "error", err)
 		}
-		slotUri, err := starknet.GetSlotUri(rpc, event.FromAddress, slot)
+		slotUri, err := catalog.FetchSlotUri(db, rpc, event.FromAddress, slot)
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot_uri", "error", err)
 		}
@@ -91,7 +92,7 @@ func ProjectSlotChangedSubscriber(storage indexer.Storage, db *gorm.DB, rpc star
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot of token_id", "error", err)
 		}
-		slotUri, err := starknet.GetSlotUri(rpc, event.FromAddress, slot)
+		slotUri, err := catalog.FetchSlotUri(db, rpc, event.FromAddress, slot)
 		if err != nil {
 			log.Error("project:transfer -> failed to get slot_uri", "error", err)
 		}
@@ -0,0 +1,40 @@
+package subscriber
+
+import (
+	"github.com/carbonable/leaderboard/internal/indexer"
+	"github.com/carbonable/leaderboard/internal/leaderboard"
+	"github.com/charmbracelet/log"
+	"github.com/nats-io/nats.go"
+	"gorm.io/gorm"
+)
+
+// Handling referral `Referral` event
+func ReferralRegisteredSubscriber(storage indexer.Storage, db *gorm.DB) nats.MsgHandler {
+	return func(m *nats.Msg) {
+		event, err := decodeEvent("referral:registered", storage.Get([]byte("EVENT#"+string(m.Data))))
+		if err != nil {
+			return
+		}
+
+		log.Info("referral:registered", "event", event)
+		data := map[string]string{
+			"referee":  event.Data[0],
+			"referrer": event.Data[1],
+		}
+
+		evt := leaderboard.DomainEventFromStarknetEvent(event, "referral:registered", data["referee"], data, map[string]string{})
+		db.Create(&evt)
+
+		if _, err := leaderboard.ReferralFromEvent(db, *evt); err != nil {
+			log.Error("referral:registered -> failed to save referral", "error", err)
+		}
+	}
+}
+
+func RegisterReferralSubscribers(args *SubscriberArgs) error {
+	if _, err := args.nc.Subscribe("referral:registered", ReferralRegisteredSubscriber(args.storage, args.db)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -86,6 +86,10 @@ func RegisterSubscribers(args *SubscriberArgs) error {
 		log.Error("failed to register migrator subscribers", "error", err)
 		return err
 	}
+	if err := RegisterReferralSubscribers(args); err != nil {
+		log.Error("failed to register referral subscribers", "error", err)
+		return err
+	}
 
 	return nil
 }
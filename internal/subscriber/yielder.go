@@ -19,7 +19,7 @@ func YielderWithdrawSubscriber(storage indexer.Storage, db *gorm.DB, rpc starkne
 
 		log.Info("yielder:withdraw", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"value":   event.Data[1],
@@ -41,7 +41,7 @@ func YielderDepositSubscriber(storage indexer.Storage, db *gorm.DB, rpc starknet
 
 		log.Info("yielder:deposit", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"value":   event.Data[1],
@@ -63,7 +63,7 @@ func YielderClaimSubscriber(storage indexer.Storage, db *gorm.DB, rpc starknet.S
 
 		log.Info("yielder:claim", "event", event)
 
-		metadata := getMetadataFromEvent(rpc, event.FromAddress)
+		metadata := getMetadataFromEvent(db, rpc, event.FromAddress)
 		data := map[string]string{
 			"address": event.Data[0],
 			"amount":  event.Data[1],